@@ -0,0 +1,240 @@
+package di
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BuildOption is an option that can be given to the Build method of the EnhancedBuilder
+// to customize the behavior of the generated Container.
+type BuildOption func(o *buildOptions)
+
+// buildOptions contains the options given to the Build method of the EnhancedBuilder.
+type buildOptions struct {
+	maxBuildDepth   int
+	typeResolution  TypeResolution
+	denseGraph      bool
+	inferScopes     bool
+	trackBuilds     bool
+	profiles        []string
+	forbidUnshared  []string
+	requireUnshared []string
+
+	// dryRun is only set by the Validate method of the EnhancedBuilder.
+	dryRun *dryRunRecorder
+}
+
+// newBuildOptions applies the given options on the default options.
+func newBuildOptions(opts []BuildOption) *buildOptions {
+	o := &buildOptions{
+		maxBuildDepth:   0,
+		typeResolution:  LastWins,
+		denseGraph:      false,
+		inferScopes:     false,
+		trackBuilds:     false,
+		profiles:        []string{},
+		forbidUnshared:  []string{},
+		requireUnshared: []string{},
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+
+	return o
+}
+
+// WithMaxBuildDepth limits the number of nested objects that can be built at the same time.
+// If an object needs to be built while n objects are already being built in the same chain,
+// the object is not built and an error is returned instead.
+// The chain includes the objects built in the parent containers and in the containers created by the unscoped getters.
+// It is a safety net for cycles that can not be detected otherwise,
+// like the ones created by the use of UnscopedGet in a Build function.
+//
+// The default value is 0, meaning that the depth is not limited.
+func WithMaxBuildDepth(n int) BuildOption {
+	return func(o *buildOptions) {
+		o.maxBuildDepth = n
+	}
+}
+
+// WithDenseDependencyGraph changes the structure used by the containers
+// to store the dependencies between the built objects.
+// These dependencies are used to determine in which order the objects are closed when a container is deleted.
+// The default structure is based on maps. With this option, it is based on slices instead.
+// It is faster and allocates less memory when the containers build many objects,
+// but it uses more memory when they only build a few objects among many definitions.
+func WithDenseDependencyGraph() BuildOption {
+	return func(o *buildOptions) {
+		o.denseGraph = true
+	}
+}
+
+// WithInferScopes sets the scope of the definitions without Scope from their DependsOn field.
+// Such a definition is placed in the most specific scope of its dependencies,
+// or in the most generic scope if it does not have dependencies.
+// The definitions that have a Scope must not depend on a definition in a more specific scope.
+// The Build method returns an error if this is the case, if a dependency is not defined,
+// or if the dependencies contain a cycle.
+//
+// Without this option, the definitions without Scope are in the most generic scope.
+func WithInferScopes() BuildOption {
+	return func(o *buildOptions) {
+		o.inferScopes = true
+	}
+}
+
+// WithBuildTracking makes the containers keep track of the objects that are being built,
+// so that they can be listed with the InProgressBuilds method of the Container.
+// Without this option, InProgressBuilds always returns an empty slice.
+// The tracking has a small cost on each build, so it is disabled by default.
+func WithBuildTracking() BuildOption {
+	return func(o *buildOptions) {
+		o.trackBuilds = true
+	}
+}
+
+// WithProfile activates a profile.
+// Only the definitions without profiles and the definitions including an active profile in their Profiles field
+// are added to the container. The other definitions are ignored, as if they were never added to the builder.
+// WithProfile can be used several times to activate several profiles.
+func WithProfile(profile string) BuildOption {
+	return func(o *buildOptions) {
+		o.profiles = append(o.profiles, profile)
+	}
+}
+
+// WithForbidUnshared asserts that the definitions with the given names are shared.
+// The Build method returns an error if one of these definitions has its Unshared field set to true.
+// It can be used to protect resources that must be singletons, like a connection pool.
+// The names that do not match any definition are ignored.
+func WithForbidUnshared(names ...string) BuildOption {
+	return func(o *buildOptions) {
+		o.forbidUnshared = append(o.forbidUnshared, names...)
+	}
+}
+
+// WithRequireUnshared asserts that the definitions with the given names are unshared.
+// The Build method returns an error if one of these definitions does not have its Unshared field set to true.
+// The names that do not match any definition are ignored.
+func WithRequireUnshared(names ...string) BuildOption {
+	return func(o *buildOptions) {
+		o.requireUnshared = append(o.requireUnshared, names...)
+	}
+}
+
+// checkUnshared checks that the definitions match
+// the WithForbidUnshared and WithRequireUnshared options.
+func (o *buildOptions) checkUnshared(definitions []Def) error {
+	errBuilder := &multiErrBuilder{}
+
+	for _, def := range definitions {
+		if def.Unshared && containsString(o.forbidUnshared, def.Name) {
+			errBuilder.Add(fmt.Errorf("the definition `%s` must be shared but its Unshared field is true", def.Name))
+		}
+		if !def.Unshared && containsString(o.requireUnshared, def.Name) {
+			errBuilder.Add(fmt.Errorf("the definition `%s` must be unshared but its Unshared field is false", def.Name))
+		}
+	}
+
+	return errBuilder.Build()
+}
+
+// containerConfig contains the settings of a Container.
+// It is shared by the Container and all its sub-containers.
+type containerConfig struct {
+	maxBuildDepth   int
+	typeResolution  TypeResolution
+	warningHandlers []func(def Def, err error)
+	buildHooks      []func(def Def, obj interface{}, d time.Duration)
+	closeHooks      []func(def Def, err error)
+	metrics         Metrics
+
+	// objectWrapper can replace the built objects before they are stored. It can be nil.
+	objectWrapper func(def Def, obj interface{}) interface{}
+
+	// logger receives the errors of the containers. It can be nil.
+	logger Logger
+
+	// pools contains the pools of the pooled definitions, indexed by definition index.
+	pools map[int]*sync.Pool
+
+	// newDependencyTracker creates the structure used to store the dependencies of a container.
+	newDependencyTracker func() dependencyTracker
+
+	// closeHints contains the edges added to the dependencies when a container is deleted,
+	// to respect the CloseBefore and CloseAfter fields of the definitions.
+	// The first definition index must be closed before the second one.
+	closeHints [][2]int
+
+	// dryRun records the objects requested by the Build functions. It is only set by the Validate method.
+	dryRun *dryRunRecorder
+
+	// trackBuilds is true if the chains of builds are registered in inProgress.
+	trackBuilds bool
+
+	// inProgress contains the chains of builds that are running, if trackBuilds is true.
+	// The keys are the *buildProgress of the chains. The values are not used.
+	inProgress sync.Map
+}
+
+// newContainerConfig creates the settings of a Container from the build options.
+func newContainerConfig(o *buildOptions) *containerConfig {
+	config := &containerConfig{
+		maxBuildDepth:   o.maxBuildDepth,
+		typeResolution:  o.typeResolution,
+		trackBuilds:     o.trackBuilds,
+		warningHandlers: []func(def Def, err error){},
+		buildHooks:      []func(def Def, obj interface{}, d time.Duration){},
+		closeHooks:      []func(def Def, err error){},
+		metrics:         noopMetrics{},
+		dryRun:          o.dryRun,
+		newDependencyTracker: func() dependencyTracker {
+			return newGraph()
+		},
+	}
+
+	if o.denseGraph {
+		config.newDependencyTracker = func() dependencyTracker {
+			return newArrayGraph()
+		}
+	}
+
+	return config
+}
+
+// warn calls the warning handlers.
+// The panics in the handlers are recovered and ignored.
+func (c *containerConfig) warn(def Def, err error) {
+	for _, handler := range c.warningHandlers {
+		func() {
+			defer func() { recover() }()
+			handler(def, err)
+		}()
+	}
+}
+
+// notifyClose calls the close hooks.
+// The panics in the hooks are recovered and ignored.
+func (c *containerConfig) notifyClose(def Def, err error) {
+	for _, hook := range c.closeHooks {
+		func() {
+			defer func() { recover() }()
+			hook(def, err)
+		}()
+	}
+}
+
+// notifyBuild calls the build hooks.
+// The panics in the hooks are recovered and ignored.
+func (c *containerConfig) notifyBuild(def Def, obj interface{}, d time.Duration) {
+	for _, hook := range c.buildHooks {
+		func() {
+			defer func() { recover() }()
+			hook(def, obj, d)
+		}()
+	}
+}
@@ -103,8 +103,8 @@ func (b *Builder) add(def Def) error {
 		return fmt.Errorf("scope `%s` is not allowed", def.Scope)
 	}
 
-	if def.Build == nil {
-		return errors.New("Build can not be nil")
+	if err := def.checkBuildFunctions(); err != nil {
+		return err
 	}
 
 	b.definitions[def.Name] = def
@@ -175,30 +175,14 @@ func (b *Builder) Build() Container {
 	}
 
 	return Container{
-		core: &containerCore{
-			closed: false,
-
-			scopes:     b.scopes,
-			scopeLevel: 0,
-
-			parent:          nil,
-			children:        map[*containerCore]struct{}{},
-			unscopedChild:   nil,
-			deleteIfNoChild: false,
-
-			indexesByName:         indexesByName,
-			indexesByType:         indexesByType,
-			definitions:           definitions,
-			objects:               make([]interface{}, len(indexesByName)),
-			definitionScopeLevels: definitionScopeLevels,
-			isBuilt:               make([]int32, len(indexesByName)),
-			building:              make([]*buildingChan, len(indexesByName)),
-
-			unshared:      []interface{}{},
-			unsharedIndex: []int{},
-
-			dependencies: newGraph(),
-		},
+		core: newRootCore(
+			b.scopes,
+			definitions,
+			indexesByName,
+			indexesByType,
+			definitionScopeLevels,
+			newContainerConfig(newBuildOptions(nil)),
+		),
 		builtList: make([]int, 0, 10),
 	}
 }
@@ -0,0 +1,79 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Alias registers an alternate name for a definition.
+// In the generated Container, the alias can be used instead of the name of the target definition
+// in all the methods accepting a definition name (Get, SafeGet, NameIsDefined, ...).
+//
+// The alias can not be the name of a definition or another alias, and it can not start with "_di_generated_".
+// The target must be the name of a definition, not an alias.
+// It does not need to be added yet, but the Build method returns an error if it is never added.
+// If the target is not in the active profiles, the alias is ignored.
+func (b *EnhancedBuilder) Alias(alias, target string) error {
+	if len(b.scopes) == 0 {
+		return errors.New("the builder was not created with NewEnhancedBuilder")
+	}
+
+	if alias == "" {
+		return errors.New("the alias can not be empty")
+	}
+
+	if strings.HasPrefix(alias, generatedNamePrefix) {
+		return errors.New("the alias can not start by `" + generatedNamePrefix + "`")
+	}
+
+	if _, ok := b.definitions[alias]; ok {
+		return fmt.Errorf("could not add the alias `%s` because it is the name of a definition", alias)
+	}
+
+	if existing, ok := b.aliases[alias]; ok {
+		return fmt.Errorf("could not add the alias `%s` because it is already an alias of `%s`", alias, existing)
+	}
+
+	if _, ok := b.aliases[target]; ok {
+		return fmt.Errorf("could not add the alias `%s` because its target `%s` is an alias", alias, target)
+	}
+
+	b.aliases[alias] = target
+
+	return nil
+}
+
+// Aliases returns the aliases registered at this point.
+// The keys are the aliases and the values are the names of their target definitions.
+func (b *EnhancedBuilder) Aliases() map[string]string {
+	aliases := make(map[string]string, len(b.aliases))
+	for alias, target := range b.aliases {
+		aliases[alias] = target
+	}
+	return aliases
+}
+
+// activeAliases returns the aliases whose target is one of the given definitions.
+// It returns an error if the target of an alias was never added to the builder.
+func (b *EnhancedBuilder) activeAliases(definitions []Def) (map[string]string, error) {
+	active := make(map[string]bool, len(definitions))
+	for _, def := range definitions {
+		active[def.Name] = true
+	}
+
+	aliases := map[string]string{}
+	errBuilder := &multiErrBuilder{}
+
+	for alias, target := range b.aliases {
+		if active[target] {
+			aliases[alias] = target
+			continue
+		}
+		if _, ok := b.definitions[target]; !ok {
+			errBuilder.Add(fmt.Errorf("the alias `%s` targets `%s` which is not defined", alias, target))
+		}
+	}
+
+	return aliases, errBuilder.Build()
+}
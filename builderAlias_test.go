@@ -0,0 +1,63 @@
+package di
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnhancedBuilderAlias(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	build := func(ctn Container) (interface{}, error) { return &mockC{}, nil }
+
+	require.Nil(t, b.Add(&Def{Name: "object", Build: build}))
+	require.Nil(t, b.Alias("alias", "object"))
+	require.Nil(t, b.Alias("early", "later"))
+	require.Nil(t, b.Add(&Def{Name: "later", Build: build}))
+
+	require.NotNil(t, b.Alias("", "object"))
+	require.NotNil(t, b.Alias("_di_generated_alias", "object"))
+	require.NotNil(t, b.Alias("object", "later"), "an alias can not be the name of a definition")
+	require.NotNil(t, b.Alias("alias", "later"), "an alias can not be added twice")
+	require.NotNil(t, b.Alias("other", "alias"), "an alias can not target an alias")
+	require.NotNil(t, b.Add(&Def{Name: "alias", Build: build}), "a definition can not have the name of an alias")
+
+	require.Equal(t, map[string]string{"alias": "object", "early": "later"}, b.Aliases())
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	require.True(t, app.NameIsDefined("alias"))
+	require.True(t, app.Get("alias") == app.Get("object"))
+	require.True(t, app.Get("early") == app.Get("later"))
+
+	name, err := app.ResolveName("alias")
+	require.Nil(t, err)
+	require.Equal(t, "object", name)
+
+	require.Equal(t, map[string]string{"alias": "object", "early": "later"}, app.Aliases())
+	require.Equal(t, []string{"object", "later"}, app.Names())
+	require.Len(t, app.Definitions(), 2)
+}
+
+func TestEnhancedBuilderAliasErrors(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	build := func(ctn Container) (interface{}, error) { return &mockC{}, nil }
+
+	require.Nil(t, b.Add(&Def{Name: "test-object", Profiles: []string{"test"}, Build: build}))
+	require.Nil(t, b.Alias("object", "test-object"))
+
+	// The alias is ignored if its target is not in the active profiles.
+	app, err := b.Build()
+	require.Nil(t, err)
+	require.False(t, app.NameIsDefined("object"))
+
+	b, _ = NewEnhancedBuilder()
+	require.Nil(t, b.Alias("alias", "undefined"))
+
+	_, err = b.Build()
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "the alias `alias` targets `undefined` which is not defined")
+}
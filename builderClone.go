@@ -0,0 +1,50 @@
+package di
+
+import "time"
+
+// Clone returns a copy of the builder, with the same scopes, definitions and settings.
+// The definitions added to the copy do not affect the builder, and the other way around.
+// It allows to share a base builder between tests, and to change a few definitions in each test.
+//
+// The definition pointers given to the Add method are not bound to the Container generated by the copy.
+// The copy uses its own copies of the definitions instead, so both builders can be built independently.
+// In the Container generated by the copy, the objects must be retrieved by name, type or index.
+func (b *EnhancedBuilder) Clone() *EnhancedBuilder {
+	c := &EnhancedBuilder{
+		definitions:     make(DefMap, len(b.definitions)),
+		bindings:        make(map[string]*Def, len(b.bindings)),
+		insertionOrder:  make(map[string]int, len(b.insertionOrder)),
+		numAdded:        b.numAdded,
+		registrations:   make(map[string][]string, len(b.registrations)),
+		aliases:         b.Aliases(),
+		scopes:          b.scopes.Copy(),
+		warningHandlers: append([]func(def Def, err error){}, b.warningHandlers...),
+		buildHooks:      append([]func(def Def, obj interface{}, d time.Duration){}, b.buildHooks...),
+		closeHooks:      append([]func(def Def, err error){}, b.closeHooks...),
+		transforms:      append([]func(def Def) Def{}, b.transforms...),
+		decorators:      make(map[string][]func(prev interface{}, ctn Container) (interface{}, error), len(b.decorators)),
+		metrics:         b.metrics,
+		objectWrapper:   b.objectWrapper,
+		logger:          b.logger,
+	}
+
+	for name, def := range b.definitions {
+		def := def.deepCopy()
+		c.definitions[name] = def
+		c.bindings[name] = &def
+	}
+
+	for name, order := range b.insertionOrder {
+		c.insertionOrder[name] = order
+	}
+
+	for name, registrations := range b.registrations {
+		c.registrations[name] = append([]string{}, registrations...)
+	}
+
+	for name, decorators := range b.decorators {
+		c.decorators[name] = append([]func(prev interface{}, ctn Container) (interface{}, error){}, decorators...)
+	}
+
+	return c
+}
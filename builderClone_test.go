@@ -0,0 +1,59 @@
+package di
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnhancedBuilderClone(t *testing.T) {
+	base, _ := NewEnhancedBuilder()
+
+	built := []string{}
+	base.OnBuild(func(def Def, obj interface{}, d time.Duration) {
+		built = append(built, def.Name)
+	})
+
+	clockDef := &Def{
+		Name:  "clock",
+		Build: func(ctn Container) (interface{}, error) { return "real", nil },
+		Tags:  []Tag{{Name: "tag"}},
+	}
+	base.Add(clockDef)
+	base.Add(&Def{
+		Name:  "service",
+		Build: func(ctn Container) (interface{}, error) { return "service-" + ctn.Get("clock").(string), nil },
+	})
+	base.Alias("time", "clock")
+
+	clone := base.Clone()
+	clone.Add(&Def{
+		Name:  "clock",
+		Build: func(ctn Container) (interface{}, error) { return "frozen", nil },
+	})
+	clone.Add(&Def{
+		Name:  "extra",
+		Build: func(ctn Container) (interface{}, error) { return "extra", nil },
+	})
+
+	require.False(t, base.NameIsDefined("extra"))
+	require.Equal(t, []string{"tag"}, []string{base.Definitions()["clock"].Tags[0].Name})
+
+	// Both builders can be built.
+	testCtn, err := clone.Build()
+	require.Nil(t, err)
+
+	app, err := base.Build()
+	require.Nil(t, err)
+
+	require.Equal(t, "service-frozen", testCtn.Get("service"))
+	require.Equal(t, "frozen", testCtn.Get("time"))
+	require.Equal(t, "extra", testCtn.Get("extra"))
+
+	require.Equal(t, "service-real", app.Get("service"))
+	require.Equal(t, "real", app.Get(clockDef))
+	require.False(t, app.NameIsDefined("extra"))
+
+	require.Equal(t, []string{"clock", "service", "extra", "clock", "service"}, built, "the hooks should be copied")
+}
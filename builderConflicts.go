@@ -0,0 +1,45 @@
+package di
+
+import "sort"
+
+// Conflict describes definitions that were registered with the same key in an EnhancedBuilder.
+type Conflict struct {
+	// Key is the name shared by the definitions.
+	Key string
+	// Definitions describes each registration of a definition with this name,
+	// in the order they were added to the builder (e.g. "`db` added in position 3").
+	// The position counts all the definitions added to the builder, starting at 1.
+	// The definitions coming from another builder with the Merge method
+	// are described with their position in this other builder.
+	Definitions []string
+}
+
+// Conflicts returns all the collisions between the definitions added to the builder so far.
+// A definition added with the name of a previous definition replaces it,
+// so the replaced definitions are reported as a Conflict with the one that replaced them.
+// The conflicts are sorted by key.
+//
+// Conflicts does not modify the builder. It can be used to report all the collisions at once,
+// for example when the definitions are added by several independent modules.
+// Note that overriding a definition on purpose (e.g. to replace a service by a stub in a test)
+// is also reported as a conflict.
+func (b *EnhancedBuilder) Conflicts() []Conflict {
+	conflicts := []Conflict{}
+
+	for name, registrations := range b.registrations {
+		if len(registrations) < 2 {
+			continue
+		}
+
+		conflicts = append(conflicts, Conflict{
+			Key:         name,
+			Definitions: append([]string{}, registrations...),
+		})
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Key < conflicts[j].Key
+	})
+
+	return conflicts
+}
@@ -0,0 +1,33 @@
+package di
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnhancedBuilderConflicts(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	buildFunc := func(ctn Container) (interface{}, error) { return nil, nil }
+
+	b.Add(NewDef(buildFunc).SetName("b"))
+	b.Add(NewDef(buildFunc).SetName("a"))
+	b.Add(NewDef(buildFunc))
+	b.Add(NewDef(buildFunc))
+	require.Equal(t, []Conflict{}, b.Conflicts())
+
+	b.Add(NewDef(buildFunc).SetName("b"))
+	b.Add(NewDef(buildFunc).SetName("a"))
+	b.Add(NewDef(buildFunc).SetName("b"))
+
+	require.Equal(t, []Conflict{
+		{Key: "a", Definitions: []string{"`a` added in position 2", "`a` added in position 6"}},
+		{Key: "b", Definitions: []string{"`b` added in position 1", "`b` added in position 5", "`b` added in position 7"}},
+	}, b.Conflicts())
+	require.Equal(t, b.Conflicts(), b.Clone().Conflicts())
+
+	err := b.AddMap("", map[string]func(ctn Container) (interface{}, error){"c": buildFunc, "": buildFunc})
+	require.NotNil(t, err)
+	require.Equal(t, 2, len(b.Conflicts()), "a failed Add does not create a conflict")
+}
@@ -0,0 +1,46 @@
+package di
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CheckCycles looks for cycles in the dependencies between the definitions registered at this point.
+// Like Validate, it creates a temporary Container and calls the Build function of each definition once,
+// recording the objects requested by each Build function. These requests form a dependency graph,
+// and CheckCycles returns an error wrapping ErrCycle for each group of definitions that depend on each other.
+//
+// It allows to detect a cycle at startup, even if it is only reached by a code path that is rarely used.
+// But like Validate, it is a best-effort check. It calls the Build functions, so their side effects happen,
+// and a dependency is only detected if the Build function actually requests it.
+// The definitions are not bound to the temporary Container, so the Build method can still be called afterwards.
+// The options are the ones of the Build method.
+func (b *EnhancedBuilder) CheckCycles(opts ...BuildOption) error {
+	if len(b.scopes) == 0 {
+		return fmt.Errorf("the builder was not created with NewEnhancedBuilder")
+	}
+
+	recorder := b.newDryRunRecorder()
+	recorder.dependencies = newGraph()
+
+	names, err := b.dryRun(recorder, opts)
+	if err != nil {
+		return err
+	}
+
+	if _, err := recorder.dependencies.TopologicalOrdering(); err == nil {
+		return nil
+	}
+
+	errBuilder := &multiErrBuilder{}
+
+	for _, component := range recorder.dependencies.StronglyConnectedComponents() {
+		cycle := make([]string, len(component))
+		for i, index := range component {
+			cycle[i] = "`" + names[index] + "`"
+		}
+		errBuilder.Add(fmt.Errorf("%w between %s", ErrCycle, strings.Join(cycle, ", ")))
+	}
+
+	return errBuilder.Build()
+}
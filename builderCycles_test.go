@@ -0,0 +1,51 @@
+package di
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnhancedBuilderCheckCycles(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	dependsOn := func(names ...string) func(ctn Container) (interface{}, error) {
+		return func(ctn Container) (interface{}, error) {
+			for _, name := range names {
+				ctn.Get(name)
+			}
+			return "obj", nil
+		}
+	}
+
+	b.Add(&Def{Name: "a", Build: dependsOn("b")})
+	b.Add(&Def{Name: "b", Build: dependsOn("c")})
+	b.Add(&Def{Name: "c", Build: dependsOn("a", "d")})
+	b.Add(&Def{Name: "d", Build: dependsOn()})
+	b.Add(&Def{Name: "e", Build: dependsOn("a", "d")})
+	b.Add(&Def{Name: "f", Scope: Request, Build: dependsOn("f")})
+
+	err := b.CheckCycles()
+	require.NotNil(t, err)
+	require.True(t, errors.Is(err, ErrCycle))
+	require.Equal(
+		t,
+		ErrCycle.Error()+" between `a`, `b`, `c` AND "+ErrCycle.Error()+" between `f`",
+		err.Error(),
+	)
+
+	// The builder can still be built.
+	_, err = b.Build()
+	require.Nil(t, err)
+}
+
+func TestEnhancedBuilderCheckCyclesWithoutCycle(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{Name: "a", Build: func(ctn Container) (interface{}, error) { return ctn.Get("b"), nil }})
+	b.Add(&Def{Name: "b", Build: func(ctn Container) (interface{}, error) { return "b", nil }})
+
+	require.Nil(t, b.CheckCycles())
+	require.NotNil(t, (&EnhancedBuilder{}).CheckCycles())
+}
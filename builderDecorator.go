@@ -0,0 +1,100 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Decorate registers a function that wraps the object of the definition with the given name.
+// The definition needs to be added to the builder before Decorate is called,
+// but it does not need to be created in the same package.
+//
+// When the object is built, the definition Build function is called first,
+// and its result is given to the decorate function. The object returned by the decorate function
+// is the one saved in the Container and returned when the definition is retrieved.
+// If Decorate is called several times for the same name, the decorators are applied in the order they were registered.
+//
+// The Close function of the definition (and its cleanup function if it uses BuildWithCleanup)
+// is still called on the original object, not on the decorated one.
+// If the decorate function returns an error, the original object is closed right away.
+//
+// The decorators are applied when the Build method is called, before the transforms.
+func (b *EnhancedBuilder) Decorate(name string, decorate func(prev interface{}, ctn Container) (interface{}, error)) error {
+	if decorate == nil {
+		return errors.New("the decorate function can not be nil")
+	}
+
+	if _, ok := b.definitions[name]; !ok {
+		return fmt.Errorf("could not decorate `%s` because %w", name, ErrNotDefined)
+	}
+
+	b.decorators[name] = append(b.decorators[name], decorate)
+
+	return nil
+}
+
+// decorateDefinition returns a copy of the definition whose Build function applies the decorate function.
+// If the original object needs to be closed, the Close function is moved in the cleanup function of BuildWithCleanup,
+// so that it receives the original object instead of the decorated one.
+func decorateDefinition(def Def, decorate func(prev interface{}, ctn Container) (interface{}, error)) Def {
+	prevDef := def
+
+	if def.BuildWithCleanup == nil && def.Close == nil && def.CloseWithContext == nil {
+		def.Build = func(ctn Container) (interface{}, error) {
+			obj, err := prevDef.Build(ctn)
+			if err != nil && (obj == nil || !errors.Is(err, ErrDegraded)) {
+				return nil, err
+			}
+
+			decorated, decorateErr := decorate(obj, ctn)
+			if decorateErr != nil {
+				return nil, fmt.Errorf("could not decorate `%s`: %w", def.Name, decorateErr)
+			}
+
+			return decorated, err
+		}
+
+		return def
+	}
+
+	def.Build = nil
+	def.Close = nil
+	def.CloseWithContext = nil
+	def.BuildWithCleanup = func(ctn Container) (interface{}, func() error, error) {
+		var obj interface{}
+		var cleanup func() error
+		var err error
+
+		if prevDef.BuildWithCleanup != nil {
+			obj, cleanup, err = prevDef.BuildWithCleanup(ctn)
+		} else {
+			obj, err = prevDef.Build(ctn)
+		}
+
+		if err != nil && (obj == nil || !errors.Is(err, ErrDegraded)) {
+			return nil, nil, err
+		}
+
+		var closePrev func() error
+
+		if closeFunc := prevDef.closeFunc(cleanup); closeFunc != nil {
+			closePrev = func() error {
+				return closeFunc(obj)
+			}
+		}
+
+		decorated, decorateErr := decorate(obj, ctn)
+		if decorateErr != nil {
+			if closePrev != nil {
+				if closeErr := closePrev(); closeErr != nil {
+					return nil, nil, fmt.Errorf("could not decorate `%s`: %w (the original object could not be closed: %+v)", def.Name, decorateErr, closeErr)
+				}
+			}
+			return nil, nil, fmt.Errorf("could not decorate `%s`: %w", def.Name, decorateErr)
+		}
+
+		return decorated, closePrev, err
+	}
+
+	return def
+}
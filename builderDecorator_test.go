@@ -0,0 +1,114 @@
+package di
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockDecorated struct {
+	Prev interface{}
+	Name string
+}
+
+func TestEnhancedBuilderDecorate(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	closed := []interface{}{}
+
+	def := &Def{
+		Name: "service",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, obj)
+			return nil
+		},
+	}
+
+	b.Add(def)
+	b.Add(&Def{
+		Name: "dependency",
+		Build: func(ctn Container) (interface{}, error) {
+			return "dependency", nil
+		},
+	})
+
+	require.NotNil(t, b.Decorate("unknown", func(prev interface{}, ctn Container) (interface{}, error) {
+		return prev, nil
+	}))
+	require.NotNil(t, b.Decorate("service", nil))
+
+	err := b.Decorate("service", func(prev interface{}, ctn Container) (interface{}, error) {
+		return &mockDecorated{Prev: prev, Name: ctn.Get("dependency").(string)}, nil
+	})
+	require.Nil(t, err)
+	err = b.Decorate("service", func(prev interface{}, ctn Container) (interface{}, error) {
+		return &mockDecorated{Prev: prev, Name: "outer"}, nil
+	})
+	require.Nil(t, err)
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	obj := app.Get(def).(*mockDecorated)
+	require.Equal(t, "outer", obj.Name)
+	inner := obj.Prev.(*mockDecorated)
+	require.Equal(t, "dependency", inner.Name)
+	original := inner.Prev.(*mockA)
+	require.True(t, obj == app.Get("service"))
+
+	require.Nil(t, app.Delete())
+	require.Len(t, closed, 1)
+	require.True(t, closed[0] == original)
+}
+
+func TestEnhancedBuilderDecorateWithoutClose(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name:     "service",
+		Unshared: true,
+		Build: func(ctn Container) (interface{}, error) {
+			return "service", nil
+		},
+	})
+
+	b.Decorate("service", func(prev interface{}, ctn Container) (interface{}, error) {
+		return prev.(string) + "-decorated", nil
+	})
+
+	app, _ := b.Build()
+	require.Equal(t, "service-decorated", app.Get("service"))
+	require.Empty(t, app.core.unshared)
+}
+
+func TestEnhancedBuilderDecorateError(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	closed := false
+
+	b.Add(&Def{
+		Name: "service",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+		Close: func(obj interface{}) error {
+			closed = true
+			return nil
+		},
+	})
+
+	decorateErr := errors.New("decorate error")
+
+	b.Decorate("service", func(prev interface{}, ctn Container) (interface{}, error) {
+		return nil, decorateErr
+	})
+
+	app, _ := b.Build()
+	_, err := app.SafeGet("service")
+	require.NotNil(t, err)
+	require.True(t, closed)
+}
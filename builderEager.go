@@ -0,0 +1,113 @@
+package di
+
+import (
+	"context"
+	"fmt"
+)
+
+// BuildEagerContext works like Build, but it also builds all the shared objects
+// of the most generic scope before returning the Container,
+// except the ones whose definition has its Lazy field set to true.
+// It allows to detect the errors at startup instead of when the objects are first retrieved.
+//
+// The context bounds the duration of the whole eager construction.
+// If the context is done before all the objects are built,
+// the error identifies the definition that was building when it happened, and the dependency it was waiting for,
+// and the Container is deleted to close all the objects that were already built.
+// A Build function that is running when the context is done is not interrupted,
+// but its object is closed as soon as it is created.
+// The Build functions can retrieve the context with the Context method of their Container,
+// for example to stop dialing a network when the deadline is exceeded.
+func (b *EnhancedBuilder) BuildEagerContext(ctx context.Context, opts ...BuildOption) (Container, error) {
+	ctn, err := b.Build(opts...)
+	if err != nil {
+		return ctn, err
+	}
+
+	if err := buildEagerObjects(ctx, ctn); err != nil {
+		ctn.Delete()
+		return newClosedContainer(), err
+	}
+
+	return ctn, nil
+}
+
+// BuildEager works like BuildEagerContext, without limiting the duration of the eager construction.
+// It returns the first build error instead of a Container.
+// The unshared definitions, the lazy definitions and the definitions of the other scopes are not built.
+func (b *EnhancedBuilder) BuildEager(opts ...BuildOption) (Container, error) {
+	return b.BuildEagerContext(context.Background(), opts...)
+}
+
+// buildEagerObjects builds all the shared objects of the Container scope that are not lazy, in the definitions order.
+// It stops at the first error or when the context is done.
+func buildEagerObjects(ctx context.Context, ctn Container) error {
+	for _, def := range ctn.core.definitions {
+		if def.Unshared || def.Lazy || def.Scope != ctn.core.scopes[0] {
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("could not build `%s` eagerly because the context is done: %w", def.Name, err)
+		}
+
+		if err := buildEagerObject(ctx, ctn, def); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// buildEagerObject builds the object of the given definition,
+// with a Container holding the context,
+// but it stops waiting for the Build function if the context is done.
+// In this case, the error contains the chain of definitions that was being built.
+func buildEagerObject(ctx context.Context, ctn Container, def Def) error {
+	ctn = ctn.WithContext(ctx)
+
+	if ctx.Done() == nil {
+		_, err := ctn.SafeGet(def.builderIndex)
+		return err
+	}
+
+	// The builds of the chain are tracked, even without the WithBuildTracking option,
+	// to know which dependency was being built when the context is done.
+	progress := &buildProgress{core: ctn.core}
+	ctn.progress = progress
+
+	errChan := make(chan error, 1)
+
+	go func() {
+		if ctn.core.config.trackBuilds {
+			ctn.core.config.inProgress.Store(progress, nil)
+			defer ctn.core.config.inProgress.Delete(progress)
+		}
+		_, err := ctn.SafeGet(def.builderIndex)
+		errChan <- err
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+		return formatEagerContextError(def, progress.get(), ctx.Err())
+	}
+}
+
+// formatEagerContextError formats the error that happens when the context is done
+// while the object of the given definition is being built eagerly.
+// The chain contains the definitions that were being built, from def to the dependency that was running.
+func formatEagerContextError(def Def, chain []string, err error) error {
+	if len(chain) == 0 {
+		return fmt.Errorf("could not build `%s` eagerly because the context is done: %w", def.Name, err)
+	}
+
+	return fmt.Errorf(
+		"could not build `%s` eagerly because the context is done while `%s` was being built, chain: %v: %w",
+		def.Name,
+		chain[len(chain)-1],
+		chain,
+		err,
+	)
+}
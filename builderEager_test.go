@@ -0,0 +1,215 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildEagerContext(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	built := []string{}
+
+	b.Add(&Def{
+		Name: "shared",
+		Build: func(ctn Container) (interface{}, error) {
+			built = append(built, "shared")
+			return &mockA{}, nil
+		},
+	})
+	b.Add(&Def{
+		Name: "lazy",
+		Lazy: true,
+		Build: func(ctn Container) (interface{}, error) {
+			built = append(built, "lazy")
+			return &mockA{}, nil
+		},
+	})
+	b.Add(&Def{
+		Name:     "unshared",
+		Unshared: true,
+		Build: func(ctn Container) (interface{}, error) {
+			built = append(built, "unshared")
+			return &mockA{}, nil
+		},
+	})
+	b.Add(&Def{
+		Name:  "request",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			built = append(built, "request")
+			return &mockA{}, nil
+		},
+	})
+
+	app, err := b.BuildEagerContext(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, []string{"shared"}, built)
+	require.True(t, app.Snapshot().IsBuilt("shared"))
+	require.False(t, app.Snapshot().IsBuilt("lazy"))
+
+	app.Get("lazy")
+	require.Equal(t, []string{"shared", "lazy"}, built)
+}
+
+func TestBuildEagerContextError(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	closed := false
+
+	b.Add(&Def{
+		Name: "o1",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+		Close: func(obj interface{}) error {
+			closed = true
+			return nil
+		},
+	})
+	b.Add(&Def{
+		Name: "o2",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, errors.New("build error")
+		},
+	})
+
+	app, err := b.BuildEagerContext(context.Background())
+	require.NotNil(t, err)
+	require.True(t, app.IsClosed())
+	require.True(t, closed)
+}
+
+func TestBuildEagerContextDeadline(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	var m sync.Mutex
+	closed := []string{}
+
+	closeFunc := func(name string) func(obj interface{}) error {
+		return func(obj interface{}) error {
+			m.Lock()
+			defer m.Unlock()
+			closed = append(closed, name)
+			return nil
+		}
+	}
+
+	b.Add(&Def{
+		Name: "fast",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+		Close: closeFunc("fast"),
+	})
+	b.Add(&Def{
+		Name: "slow",
+		Build: func(ctn Container) (interface{}, error) {
+			time.Sleep(50 * time.Millisecond)
+			return &mockA{}, nil
+		},
+		Close: closeFunc("slow"),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	app, err := b.BuildEagerContext(ctx)
+	require.NotNil(t, err)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+	require.Contains(t, err.Error(), "`slow`")
+	require.True(t, app.IsClosed())
+
+	time.Sleep(100 * time.Millisecond)
+
+	m.Lock()
+	defer m.Unlock()
+	require.Equal(t, []string{"fast", "slow"}, closed)
+}
+
+func TestBuildEagerContextNestedDeadline(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	dbCtx := make(chan context.Context, 1)
+
+	b.Add(&Def{
+		Name: "service",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.SafeGet("db")
+		},
+	})
+	b.Add(&Def{
+		Name: "db",
+		Build: func(ctn Container) (interface{}, error) {
+			dbCtx <- ctn.Context()
+			<-release
+			return &mockA{}, nil
+		},
+	})
+
+	_, err := b.BuildEagerContext(ctx)
+	require.NotNil(t, err)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+	require.Contains(t, err.Error(), "could not build `service` eagerly")
+	require.Contains(t, err.Error(), "while `db` was being built, chain: [service db]")
+	require.True(t, <-dbCtx == ctx, "the Build functions should receive the context")
+}
+
+func TestBuildEager(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	built := []string{}
+
+	b.Add(&Def{
+		Name: "shared",
+		Build: func(ctn Container) (interface{}, error) {
+			built = append(built, "shared")
+			return &mockA{}, nil
+		},
+	})
+	b.Add(&Def{
+		Name:     "unshared",
+		Unshared: true,
+		Build: func(ctn Container) (interface{}, error) {
+			built = append(built, "unshared")
+			return &mockA{}, nil
+		},
+	})
+	b.Add(&Def{
+		Name:  "request",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, errors.New("request error")
+		},
+	})
+
+	app, err := b.BuildEager()
+	require.Nil(t, err)
+	require.Equal(t, []string{"shared"}, built)
+	require.True(t, app.Snapshot().IsBuilt("shared"))
+
+	b, _ = NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name: "failing",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, errors.New("build error")
+		},
+	})
+
+	app, err = b.BuildEager()
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "build error")
+	require.True(t, app.IsClosed())
+}
@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const generatedNamePrefix = "_di_generated_"
@@ -21,11 +22,21 @@ const generatedNamePrefix = "_di_generated_"
 // The definitions are updated when the Build method is called.
 // That allows to retrieve objects by their definitions which is faster than retrieving them by name.
 type EnhancedBuilder struct {
-	definitions    DefMap
-	bindings       map[string]*Def
-	insertionOrder map[string]int
-	numAdded       int
-	scopes         ScopeList
+	definitions     DefMap
+	bindings        map[string]*Def
+	insertionOrder  map[string]int
+	numAdded        int
+	registrations   map[string][]string
+	aliases         map[string]string
+	scopes          ScopeList
+	warningHandlers []func(def Def, err error)
+	buildHooks      []func(def Def, obj interface{}, d time.Duration)
+	closeHooks      []func(def Def, err error)
+	transforms      []func(def Def) Def
+	decorators      map[string][]func(prev interface{}, ctn Container) (interface{}, error)
+	metrics         Metrics
+	objectWrapper   func(def Def, obj interface{}) interface{}
+	logger          Logger
 }
 
 // NewEnhancedBuilder is the only way to create a working EnhancedBuilder.
@@ -44,11 +55,18 @@ func NewEnhancedBuilder(scopes ...string) (*EnhancedBuilder, error) {
 	}
 
 	return &EnhancedBuilder{
-		definitions:    DefMap{},
-		bindings:       map[string]*Def{},
-		insertionOrder: map[string]int{},
-		numAdded:       0,
-		scopes:         scopes,
+		definitions:     DefMap{},
+		bindings:        map[string]*Def{},
+		insertionOrder:  map[string]int{},
+		numAdded:        0,
+		registrations:   map[string][]string{},
+		aliases:         map[string]string{},
+		scopes:          scopes,
+		warningHandlers: []func(def Def, err error){},
+		buildHooks:      []func(def Def, obj interface{}, d time.Duration){},
+		closeHooks:      []func(def Def, err error){},
+		transforms:      []func(def Def) Def{},
+		decorators:      map[string][]func(prev interface{}, ctn Container) (interface{}, error){},
 	}, nil
 }
 
@@ -101,6 +119,17 @@ func (b *EnhancedBuilder) NameIsDefined(name string) bool {
 // That allows to build an object not only from its name
 // but also from its definition which happens to be faster.
 func (b *EnhancedBuilder) Add(def *Def) error {
+	if err := b.checkAdd(def); err != nil {
+		return err
+	}
+
+	b.add(def)
+
+	return nil
+}
+
+// checkAdd returns an error if the definition can not be added to the builder with the Add method.
+func (b *EnhancedBuilder) checkAdd(def *Def) error {
 	if def == nil {
 		return errors.New("the definition can not be nil")
 	}
@@ -113,33 +142,215 @@ func (b *EnhancedBuilder) Add(def *Def) error {
 		return fmt.Errorf("scope `%s` is not allowed", def.Scope)
 	}
 
-	if def.Build == nil {
-		return errors.New("the Build function can not be nil")
+	if err := def.checkBuildFunctions(); err != nil {
+		return err
+	}
+
+	if err := def.checkPooled(); err != nil {
+		return err
+	}
+
+	if err := def.checkBuildForScope(b.scopes); err != nil {
+		return err
 	}
 
 	if strings.HasPrefix(def.Name, generatedNamePrefix) {
 		return errors.New("the definition name can not start by `" + generatedNamePrefix + "`")
 	}
 
-	defStruct := *def
+	if _, ok := b.aliases[def.Name]; ok {
+		return fmt.Errorf("the definition name `%s` is already used by an alias", def.Name)
+	}
+
+	return nil
+}
+
+// add adds a definition that has already been checked with checkAdd.
+func (b *EnhancedBuilder) add(def *Def) {
+	defStruct := def.deepCopy()
 
 	if defStruct.Name == "" {
 		defStruct.Name = generatedNamePrefix + strconv.Itoa(b.numAdded)
 	}
 
-	if defStruct.Is != nil {
-		defStruct.Is = make([]reflect.Type, len(def.Is))
-		copy(defStruct.Is, def.Is)
-	}
-
 	b.definitions[defStruct.Name] = defStruct
 	b.bindings[defStruct.Name] = def
 	b.insertionOrder[defStruct.Name] = b.numAdded
 	b.numAdded++
+	b.registrations[defStruct.Name] = append(
+		b.registrations[defStruct.Name],
+		fmt.Sprintf("`%s` added in position %d", defStruct.Name, b.numAdded),
+	)
+}
+
+// deepCopy returns a copy of the definition that does not share its slices and maps with the original.
+func (d *Def) deepCopy() Def {
+	c := *d
+
+	if c.Is != nil {
+		c.Is = make([]reflect.Type, len(d.Is))
+		copy(c.Is, d.Is)
+	}
+
+	if c.Profiles != nil {
+		c.Profiles = make([]string, len(d.Profiles))
+		copy(c.Profiles, d.Profiles)
+	}
+
+	if c.BuildForScope != nil {
+		c.BuildForScope = make(map[string]func(ctn Container) (interface{}, error), len(d.BuildForScope))
+		for scope, build := range d.BuildForScope {
+			c.BuildForScope[scope] = build
+		}
+	}
+
+	if c.Decorators != nil {
+		c.Decorators = make([]func(ctn Container, obj interface{}) (interface{}, error), len(d.Decorators))
+		copy(c.Decorators, d.Decorators)
+	}
+
+	if c.Meta != nil {
+		c.Meta = make(map[string]interface{}, len(d.Meta))
+		for k, v := range d.Meta {
+			c.Meta[k] = v
+		}
+	}
+
+	if c.DependsOn != nil {
+		c.DependsOn = make([]string, len(d.DependsOn))
+		copy(c.DependsOn, d.DependsOn)
+	}
+
+	if c.CloseBefore != nil {
+		c.CloseBefore = make([]string, len(d.CloseBefore))
+		copy(c.CloseBefore, d.CloseBefore)
+	}
+
+	if c.CloseAfter != nil {
+		c.CloseAfter = make([]string, len(d.CloseAfter))
+		copy(c.CloseAfter, d.CloseAfter)
+	}
+
+	return c
+}
+
+// Set is a shortcut to add a definition for an already built object, in the most generic scope.
+// It returns the added definition, so that it can be used to retrieve the object once the Container is built.
+// The object is not closed when the Container is deleted.
+func (b *EnhancedBuilder) Set(name string, obj interface{}) (*Def, error) {
+	def := NewDefFor(obj).SetName(name)
+
+	if err := b.Add(def); err != nil {
+		return nil, err
+	}
+
+	return def, nil
+}
+
+// AddIf adds the definition to the Builder like Add, but only if cond is true.
+// It allows to choose the definitions depending on the environment without branching at registration time.
+// If cond is false, the definition is ignored and it is not bound to the generated Container,
+// so retrieving an object with this definition returns an error wrapping ErrNotDefined.
+// A definition with the same name that was already added is not replaced.
+func (b *EnhancedBuilder) AddIf(cond bool, def *Def) error {
+	if !cond {
+		return nil
+	}
+
+	return b.Add(def)
+}
+
+// AddMap adds one definition for each entry of the map.
+// The key of the map is the name of the definition and the value is its Build function.
+// All the definitions are added in the given scope.
+// The most generic scope is used if the scope is an empty string.
+//
+// The definitions are added in the alphabetical order of their names,
+// which is the order used to resolve the types and to build the slices.
+//
+// The entries are all validated before any definition is added.
+// If one of them is not valid, an error naming the first invalid entry is returned
+// and none of the definitions are added to the builder.
+func (b *EnhancedBuilder) AddMap(scope string, builds map[string]func(ctn Container) (interface{}, error)) error {
+	if len(b.scopes) == 0 {
+		return errors.New("the builder was not created with NewEnhancedBuilder")
+	}
+
+	if scope != "" && !b.scopes.Contains(scope) {
+		return fmt.Errorf("scope `%s` is not allowed", scope)
+	}
+
+	names := make([]string, 0, len(builds))
+	for name := range builds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	defs := make([]*Def, 0, len(names))
+
+	for _, name := range names {
+		if name == "" {
+			return errors.New("could not add the map entry with an empty name: the name is required")
+		}
+
+		def := &Def{Name: name, Scope: scope, Build: builds[name]}
+
+		if err := b.checkAdd(def); err != nil {
+			return fmt.Errorf("could not add the map entry `%s`: %+v", name, err)
+		}
+
+		defs = append(defs, def)
+	}
+
+	for _, def := range defs {
+		b.add(def)
+	}
 
 	return nil
 }
 
+// OnWarning registers a function that is called by the generated Container
+// each time something goes wrong without being an error.
+// For example, when an object is built in degraded mode (check ErrDegraded).
+// The function receives the definition of the concerned object and an error describing the problem.
+// It should be registered before calling the Build method.
+// Panics in the function are recovered and ignored.
+func (b *EnhancedBuilder) OnWarning(handler func(def Def, err error)) {
+	b.warningHandlers = append(b.warningHandlers, handler)
+}
+
+// OnBuild registers a function that is called by the generated Container each time an object is built successfully.
+// The function receives the definition of the object, the object and the duration of its construction.
+// It is called outside of the locks of the Container, so it can retrieve other objects from a Container.
+// But the object is only stored in the Container after the function returns,
+// so the function must not retrieve the object that has just been built.
+// It should be registered before calling the Build method.
+// Panics in the function are recovered and ignored.
+func (b *EnhancedBuilder) OnBuild(hook func(def Def, obj interface{}, d time.Duration)) {
+	b.buildHooks = append(b.buildHooks, hook)
+}
+
+// OnClose registers a function that is called by the generated Container each time an object is closed
+// during the deletion of a Container, in the order the objects are closed.
+// The function receives the definition of the object and the error returned by the Close function, or nil.
+// It is also called for the unshared objects. For the objects created with GetOrStore,
+// the definition only has its Name field set, with the key of the object.
+// It should be registered before calling the Build method.
+// Panics in the function are recovered and ignored.
+func (b *EnhancedBuilder) OnClose(hook func(def Def, err error)) {
+	b.closeHooks = append(b.closeHooks, hook)
+}
+
+// Transform registers a function that can rewrite the definitions when the Build method is called.
+// It is applied to each definition after its scope has been set, and before the Container is generated.
+// It can be used to wrap the Build and Close functions, to add tags or to change the scope of many definitions at once.
+// The transforms are applied in the order they were registered.
+// A transform can not change the name of a definition.
+// The definition pointers given to the Add method are updated with the transformed definitions.
+func (b *EnhancedBuilder) Transform(transform func(def Def) Def) {
+	b.transforms = append(b.transforms, transform)
+}
+
 // Build creates a Container in the most generic scope
 // with all the definitions registered in the builder.
 //
@@ -148,36 +359,83 @@ func (b *EnhancedBuilder) Add(def *Def) error {
 //
 // A definition can only belong to one container.
 // That means you can only call Build once.
-func (b *EnhancedBuilder) Build() (Container, error) {
+//
+// Options can be given to customize the behavior of the Container.
+func (b *EnhancedBuilder) Build(opts ...BuildOption) (Container, error) {
+	options := newBuildOptions(opts)
+
 	if err := checkBuilderScopes(b.scopes); err != nil {
 		return newClosedContainer(), err
 	}
 
 	// Update definition scopes.
+	// With the WithInferScopes option, they are set later from the dependencies.
 	for name, def := range b.definitions {
-		if def.Scope == "" {
+		if def.Scope == "" && !options.inferScopes {
 			def.Scope = b.scopes[0]
 		}
 		b.definitions[name] = def
 	}
 
 	// Put definitions in a slice and sort them by insertion order.
+	// The definitions that are not in the active profiles are ignored.
 	definitions := []Def{}
 
 	for _, def := range b.definitions {
-		definitions = append(definitions, def)
+		if def.isInProfiles(options.profiles) {
+			definitions = append(definitions, def)
+		}
 	}
 
 	sort.Slice(definitions, func(i, j int) bool {
 		return b.insertionOrder[definitions[i].Name] < b.insertionOrder[definitions[j].Name]
 	})
 
+	// Infer the missing scopes from the dependencies.
+	if options.inferScopes {
+		if err := inferScopes(definitions, b.scopes); err != nil {
+			return newClosedContainer(), err
+		}
+	}
+
+	// Apply the decorators and the transforms to the definitions.
+	for i, def := range definitions {
+		for _, decorate := range b.decorators[def.Name] {
+			def = decorateDefinition(def, decorate)
+		}
+		definitions[i] = def
+	}
+
+	if err := b.transformDefinitions(definitions); err != nil {
+		return newClosedContainer(), err
+	}
+
+	// Check the shared and unshared assertions.
+	if err := options.checkUnshared(definitions); err != nil {
+		return newClosedContainer(), err
+	}
+
+	// Run the custom validation of the definitions.
+	if err := validateDefinitions(definitions); err != nil {
+		return newClosedContainer(), err
+	}
+
+	// Check the aliases before binding the definitions.
+	aliases, err := b.activeAliases(definitions)
+	if err != nil {
+		return newClosedContainer(), err
+	}
+
 	// Generate the indexes based on the definitions.
-	indexesByName := make(map[string]int, len(definitions))
+	indexesByName := make(map[string]int, len(definitions)+len(aliases))
 	indexesByType := map[reflect.Type][]int{}
 	definitionScopeLevels := make([]int, len(definitions))
 
 	for index, def := range definitions {
+		if b.bindings[def.Name].builderBound {
+			return newClosedContainer(), errors.New("the definition `" + def.Name + "` was already added to another container")
+		}
+
 		// Update the bound fields of the definition.
 		def.builderBound = true
 		def.builderIndex = index
@@ -194,47 +452,130 @@ func (b *EnhancedBuilder) Build() (Container, error) {
 				break
 			}
 		}
+	}
 
-		// Update the bound definition.
-		if b.bindings[def.Name].builderBound {
-			return newClosedContainer(), errors.New("the definition `" + def.Name + "` was already added to another container")
-		}
+	for alias, target := range aliases {
+		indexesByName[alias] = indexesByName[target]
+	}
+
+	// Check the dependencies and the close hints before binding the definitions,
+	// so that the definitions can still be used in another Build if they are not valid.
+	if err := checkDependsOn(definitions, indexesByName, definitionScopeLevels); err != nil {
+		return newClosedContainer(), err
+	}
+
+	closeHints, err := newCloseHints(definitions, indexesByName)
+	if err != nil {
+		return newClosedContainer(), err
+	}
+
+	// Update the bound definitions.
+	for _, def := range definitions {
 		b.bindings[def.Name].Build = def.Build
+		b.bindings[def.Name].BuildWithCleanup = def.BuildWithCleanup
+		b.bindings[def.Name].BuildForScope = def.BuildForScope
+		b.bindings[def.Name].Decorators = def.Decorators
 		b.bindings[def.Name].Close = def.Close
+		b.bindings[def.Name].CloseWithContext = def.CloseWithContext
 		b.bindings[def.Name].Name = def.Name
 		b.bindings[def.Name].Scope = def.Scope
 		b.bindings[def.Name].Unshared = def.Unshared
 		b.bindings[def.Name].Is = def.Is
 		b.bindings[def.Name].Tags = def.Tags
+		b.bindings[def.Name].Meta = def.Meta
+		b.bindings[def.Name].Profiles = def.Profiles
+		b.bindings[def.Name].Validate = def.Validate
+		b.bindings[def.Name].Lazy = def.Lazy
+		b.bindings[def.Name].Primary = def.Primary
+		b.bindings[def.Name].Pooled = def.Pooled
+		b.bindings[def.Name].Reset = def.Reset
+		b.bindings[def.Name].DependsOn = def.DependsOn
+		b.bindings[def.Name].CloseBefore = def.CloseBefore
+		b.bindings[def.Name].CloseAfter = def.CloseAfter
+		b.bindings[def.Name].Retries = def.Retries
+		b.bindings[def.Name].RetryInFreshContainer = def.RetryInFreshContainer
+		b.bindings[def.Name].BuildTimeout = def.BuildTimeout
+		b.bindings[def.Name].Deprecated = def.Deprecated
 		b.bindings[def.Name].builderBound = true
 		b.bindings[def.Name].builderIndex = def.builderIndex
 	}
 
+	config := newContainerConfig(options)
+	config.closeHints = closeHints
+	config.warningHandlers = append(config.warningHandlers, b.warningHandlers...)
+	config.buildHooks = append(config.buildHooks, b.buildHooks...)
+	config.closeHooks = append(config.closeHooks, b.closeHooks...)
+	if b.metrics != nil {
+		config.metrics = b.metrics
+	}
+	config.objectWrapper = b.objectWrapper
+	config.logger = b.logger
+	config.pools = newObjectPools(definitions)
+
 	return Container{
-		core: &containerCore{
-			closed: false,
-
-			scopes:     b.scopes,
-			scopeLevel: 0,
-
-			parent:          nil,
-			children:        map[*containerCore]struct{}{},
-			unscopedChild:   nil,
-			deleteIfNoChild: false,
-
-			indexesByName:         indexesByName,
-			indexesByType:         indexesByType,
-			definitions:           definitions,
-			objects:               make([]interface{}, len(indexesByName)),
-			definitionScopeLevels: definitionScopeLevels,
-			isBuilt:               make([]int32, len(indexesByName)),
-			building:              make([]*buildingChan, len(indexesByName)),
-
-			unshared:      []interface{}{},
-			unsharedIndex: []int{},
-
-			dependencies: newGraph(),
-		},
+		core: newRootCore(
+			b.scopes,
+			definitions,
+			indexesByName,
+			indexesByType,
+			definitionScopeLevels,
+			config,
+		),
 		builtList: make([]int, 0, 10),
 	}, nil
 }
+
+// transformDefinitions applies the transforms to the definitions
+// and checks that the transformed definitions are still valid.
+func (b *EnhancedBuilder) transformDefinitions(definitions []Def) error {
+	for i, def := range definitions {
+		for _, transform := range b.transforms {
+			def = transform(def)
+		}
+
+		if def.Name != definitions[i].Name {
+			return fmt.Errorf("the definition `%s` can not be renamed `%s` by a transform", definitions[i].Name, def.Name)
+		}
+
+		if def.Scope == "" {
+			def.Scope = b.scopes[0]
+		}
+
+		if !b.scopes.Contains(def.Scope) {
+			return fmt.Errorf("the definition `%s` has been transformed with scope `%s` which is not allowed", def.Name, def.Scope)
+		}
+
+		if err := def.checkBuildFunctions(); err != nil {
+			return fmt.Errorf("the definition `%s` has been transformed into an invalid definition: %+v", def.Name, err)
+		}
+
+		if err := def.checkPooled(); err != nil {
+			return fmt.Errorf("the definition `%s` has been transformed into an invalid definition: %+v", def.Name, err)
+		}
+
+		if err := def.checkBuildForScope(b.scopes); err != nil {
+			return fmt.Errorf("the definition `%s` is not valid: %+v", def.Name, err)
+		}
+
+		definitions[i] = def
+	}
+
+	return nil
+}
+
+// validateDefinitions calls the Validate function of the definitions
+// and returns an error containing all the validation errors.
+func validateDefinitions(definitions []Def) error {
+	errBuilder := &multiErrBuilder{}
+
+	for _, def := range definitions {
+		if def.Validate == nil {
+			continue
+		}
+		if err := def.Validate(def); err != nil {
+			errBuilder.Add(fmt.Errorf("the definition `%s` is not valid: %+v", def.Name, err))
+		}
+	}
+
+	return errBuilder.Build()
+}
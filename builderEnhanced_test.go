@@ -1,8 +1,11 @@
 package di
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -117,6 +120,16 @@ func TestEnhancedBuilderAdd(t *testing.T) {
 	err = b.Add(NewDef(nil).SetScope(App))
 	require.NotNil(t, err, "should not be able to add a Def if Build is empty")
 
+	err = b.Add(NewDef(nil).SetBuildWithCleanup(func(ctn Container) (interface{}, func() error, error) {
+		return nil, nil, nil
+	}))
+	require.Nil(t, err)
+
+	err = b.Add(NewDef(buildFunc).SetBuildWithCleanup(func(ctn Container) (interface{}, func() error, error) {
+		return nil, nil, nil
+	}))
+	require.NotNil(t, err, "should not be able to add a Def with both Build and BuildWithCleanup")
+
 	err = b.Add(NewDef(buildFunc).SetName("_di_generated_XXX"))
 	require.NotNil(t, err, "should not be able to add a Def if the name start by _di_generated_")
 
@@ -126,10 +139,250 @@ func TestEnhancedBuilderAdd(t *testing.T) {
 	defCheckIs.Is = append(defCheckIs.Is, reflect.TypeOf(""))
 	require.Equal(t, 0, len(b.Definitions()["checkIs"].Is))
 
+	defCheckMeta := &Def{Name: "checkMeta", Build: buildFunc, Meta: map[string]interface{}{"key": "value"}}
+	err = b.Add(defCheckMeta)
+	require.Nil(t, err)
+	defCheckMeta.Meta["key"] = "updated"
+	require.Equal(t, "value", b.Definitions()["checkMeta"].Meta["key"])
+
 	err = (&EnhancedBuilder{}).Add(NewDef(buildFunc))
 	require.NotNil(t, err, "can not add definition on a not properly created builder")
 }
 
+func TestEnhancedBuilderOnBuild(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	built := []string{}
+
+	b.OnBuild(func(def Def, obj interface{}, d time.Duration) {
+		panic("the panics should be ignored")
+	})
+	b.OnBuild(func(def Def, obj interface{}, d time.Duration) {
+		built = append(built, fmt.Sprintf("%s %v", def.Name, obj))
+	})
+
+	b.Add(&Def{
+		Name: "o1",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.Get("o2").(int) + 1, nil
+		},
+	})
+	b.Add(&Def{
+		Name: "o2",
+		Build: func(ctn Container) (interface{}, error) {
+			return 1, nil
+		},
+	})
+	b.Add(&Def{
+		Name: "failing",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, errors.New("build error")
+		},
+	})
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	require.Equal(t, 2, app.Get("o1"))
+	require.Equal(t, 2, app.Get("o1"))
+	_, err = app.SafeGet("failing")
+	require.NotNil(t, err)
+
+	require.Equal(t, []string{"o2 1", "o1 2"}, built)
+}
+
+func TestEnhancedBuilderOnBuildCanRetrieveObjects(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	var app Container
+
+	b.OnBuild(func(def Def, obj interface{}, d time.Duration) {
+		if def.Name == "o1" {
+			app.Get("o2")
+		}
+	})
+
+	b.Add(&Def{Name: "o1", Build: func(ctn Container) (interface{}, error) { return 1, nil }})
+	b.Add(&Def{Name: "o2", Build: func(ctn Container) (interface{}, error) { return 2, nil }})
+
+	app, _ = b.Build()
+	app.Get("o1")
+
+	require.True(t, app.Snapshot().IsBuilt("o2"))
+}
+
+func TestEnhancedBuilderOnClose(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	closed := []string{}
+
+	b.OnClose(func(def Def, err error) {
+		panic("the panics should be ignored")
+	})
+	b.OnClose(func(def Def, err error) {
+		closed = append(closed, fmt.Sprintf("%s %s %v", def.Name, def.Scope, err != nil))
+	})
+
+	closeFunc := func(obj interface{}) error { return nil }
+
+	b.Add(&Def{
+		Name:  "o1",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.Get("o2").(int) + 1, nil
+		},
+		Close: closeFunc,
+	})
+	b.Add(&Def{
+		Name:     "o2",
+		Scope:    Request,
+		Unshared: true,
+		Build: func(ctn Container) (interface{}, error) {
+			return 1, nil
+		},
+		Close: closeFunc,
+	})
+	b.Add(&Def{
+		Name:  "failing",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return 0, nil
+		},
+		Close: func(obj interface{}) error {
+			return errors.New("close error")
+		},
+	})
+	b.Add(&Def{
+		Name:  "no-close",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return 0, nil
+		},
+	})
+
+	app, _ := b.Build()
+	req, _ := app.SubContainer()
+
+	req.Get("o1")
+	req.Get("failing")
+	req.Get("no-close")
+	req.GetOrStore("stored", func() (interface{}, error) { return 0, nil }, closeFunc)
+
+	require.NotNil(t, req.Delete())
+	require.ElementsMatch(t, []string{"o1 request false", "o2 request false", "failing request true", "stored  false"}, closed)
+	require.Less(
+		t,
+		indexOfString(closed, "o1 request false"),
+		indexOfString(closed, "o2 request false"),
+		"the dependencies are closed after the objects depending on them",
+	)
+}
+
+func TestEnhancedBuilderSet(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	obj := &mockC{SField: "value"}
+
+	def, err := b.Set("object", obj)
+	require.Nil(t, err)
+	require.Equal(t, "object", def.Name)
+
+	_, err = b.Set("_di_generated_object", obj)
+	require.NotNil(t, err)
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	require.Equal(t, App, def.Scope)
+	require.True(t, app.Get(def) == obj)
+	require.True(t, app.Get("object") == obj)
+}
+
+func TestEnhancedBuilderAddIf(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	newTracerDef := func(value string) *Def {
+		return &Def{
+			Name: "tracer",
+			Build: func(ctn Container) (interface{}, error) {
+				return value, nil
+			},
+		}
+	}
+
+	noopDef := newTracerDef("noop")
+	enabledDef := newTracerDef("enabled")
+
+	require.Nil(t, b.Add(noopDef))
+	require.Nil(t, b.AddIf(false, enabledDef))
+	require.NotNil(t, b.AddIf(true, nil))
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	require.Equal(t, "noop", app.Get("tracer"))
+	require.Equal(t, "noop", app.Get(noopDef))
+	require.Equal(t, -1, enabledDef.Index())
+
+	_, err = app.SafeGet(enabledDef)
+	require.True(t, errors.Is(err, ErrNotDefined))
+
+	b, _ = NewEnhancedBuilder()
+	require.Nil(t, b.AddIf(true, enabledDef))
+	require.True(t, b.NameIsDefined("tracer"))
+}
+
+func TestEnhancedBuilderAddMap(t *testing.T) {
+	b, err := NewEnhancedBuilder()
+	require.Nil(t, err)
+
+	buildFunc := func(ctn Container) (interface{}, error) { return "obj", nil }
+
+	err = b.AddMap(Request, map[string]func(ctn Container) (interface{}, error){
+		"b": buildFunc,
+		"a": buildFunc,
+	})
+	require.Nil(t, err)
+	require.Equal(t, Request, b.Definitions()["a"].Scope)
+	require.Equal(t, Request, b.Definitions()["b"].Scope)
+	require.Less(t, b.insertionOrder["a"], b.insertionOrder["b"], "the definitions are added in alphabetical order")
+
+	err = b.AddMap("undefined", map[string]func(ctn Container) (interface{}, error){"c": buildFunc})
+	require.NotNil(t, err, "should not be able to add definitions in an undefined scope")
+
+	err = b.AddMap("", map[string]func(ctn Container) (interface{}, error){"c": buildFunc, "d": nil})
+	require.NotNil(t, err, "should not be able to add an entry without Build function")
+	require.Contains(t, err.Error(), "`d`")
+	require.False(t, b.NameIsDefined("c"), "no definition should be added if an entry is not valid")
+
+	err = b.AddMap("", map[string]func(ctn Container) (interface{}, error){"c": buildFunc, "": buildFunc})
+	require.NotNil(t, err, "should not be able to add an entry with an empty name")
+	require.False(t, b.NameIsDefined("c"))
+
+	err = b.AddMap("", map[string]func(ctn Container) (interface{}, error){"c": buildFunc, "_di_generated_XXX": buildFunc})
+	require.NotNil(t, err, "should not be able to add an entry if the name start by _di_generated_")
+	require.False(t, b.NameIsDefined("c"))
+
+	require.Nil(t, b.Alias("e", "a"))
+	err = b.AddMap("", map[string]func(ctn Container) (interface{}, error){"c": buildFunc, "d": buildFunc, "e": buildFunc})
+	require.NotNil(t, err, "should not be able to add an entry with the name of an alias")
+	require.Contains(t, err.Error(), "`e`")
+	require.False(t, b.NameIsDefined("c"), "the entries before the invalid one should not be added")
+	require.False(t, b.NameIsDefined("d"), "the entries before the invalid one should not be added")
+
+	err = b.AddMap("", map[string]func(ctn Container) (interface{}, error){"c": buildFunc})
+	require.Nil(t, err)
+	require.Equal(t, "", b.Definitions()["c"].Scope, "the scope is set when Build is called")
+
+	app, err := b.Build()
+	require.Nil(t, err)
+	require.Equal(t, "obj", app.Get("c"))
+	require.Equal(t, App, app.Definitions()["c"].Scope)
+
+	err = (&EnhancedBuilder{}).AddMap("", map[string]func(ctn Container) (interface{}, error){"c": buildFunc})
+	require.NotNil(t, err, "can not add definitions on a not properly created builder")
+}
+
 func TestEnhancedBuilderBuild(t *testing.T) {
 	ctn, err := (&EnhancedBuilder{}).Build()
 	require.NotNil(t, err)
@@ -227,3 +480,247 @@ func TestEnhancedBuilderBuild(t *testing.T) {
 	_, err = b.Build()
 	require.NotNil(t, err, "can not build the same definition twice")
 }
+
+func TestEnhancedBuilderBuildValidate(t *testing.T) {
+	buildFunc := func(ctn Container) (interface{}, error) { return nil, nil }
+
+	requireRequestScope := func(def Def) error {
+		if def.Scope != Request {
+			return errors.New("the scope should be request")
+		}
+		return nil
+	}
+
+	b, _ := NewEnhancedBuilder()
+
+	def := NewDef(buildFunc).SetName("valid").SetScope(Request).SetValidate(requireRequestScope)
+	b.Add(def)
+
+	_, err := b.Build()
+	require.Nil(t, err)
+	require.NotNil(t, def.Validate)
+
+	// All the validation errors are returned.
+	b, _ = NewEnhancedBuilder()
+
+	b.Add(NewDef(buildFunc).SetName("invalid-1").SetValidate(requireRequestScope))
+	b.Add(NewDef(buildFunc).SetName("invalid-2").SetScope(SubRequest).SetValidate(requireRequestScope))
+	invalidDef := NewDef(buildFunc).SetName("invalid-3").SetValidate(requireRequestScope)
+	b.Add(invalidDef)
+
+	app, err := b.Build()
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "invalid-1")
+	require.Contains(t, err.Error(), "invalid-2")
+	require.Contains(t, err.Error(), "invalid-3")
+	require.True(t, app.IsClosed())
+	require.Equal(t, -1, invalidDef.Index(), "the definition should not be bound to a container")
+}
+
+func TestEnhancedBuilderBuildWithProfile(t *testing.T) {
+	newBuilder := func() (*EnhancedBuilder, map[string]*Def) {
+		b, _ := NewEnhancedBuilder()
+		defs := map[string]*Def{}
+
+		for name, profiles := range map[string][]string{
+			"always":  nil,
+			"test":    {"test"},
+			"prod":    {"prod"},
+			"not-dev": {"test", "prod"},
+		} {
+			name := name
+			defs[name] = NewDef(func(ctn Container) (interface{}, error) {
+				return name, nil
+			}).SetName(name).SetProfiles(profiles...)
+			b.Add(defs[name])
+		}
+
+		return b, defs
+	}
+
+	b, defs := newBuilder()
+	app, err := b.Build(WithProfile("test"))
+	require.Nil(t, err)
+	require.True(t, app.NameIsDefined("always"))
+	require.True(t, app.NameIsDefined("test"))
+	require.False(t, app.NameIsDefined("prod"))
+	require.True(t, app.NameIsDefined("not-dev"))
+	require.Equal(t, "test", app.Get(defs["test"]))
+	require.Equal(t, -1, defs["prod"].Index(), "an ignored definition should not be bound")
+	_, err = app.SafeGet(defs["prod"])
+	require.NotNil(t, err)
+
+	b, _ = newBuilder()
+	app, _ = b.Build(WithProfile("test"), WithProfile("prod"))
+	require.Len(t, app.Definitions(), 4)
+
+	b, _ = newBuilder()
+	app, _ = b.Build(WithProfile("dev"))
+	require.Len(t, app.Definitions(), 1)
+
+	b, _ = newBuilder()
+	app, _ = b.Build()
+	require.Len(t, app.Definitions(), 1)
+	require.True(t, app.NameIsDefined("always"))
+}
+
+func TestEnhancedBuilderTransform(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	def := NewDef(func(ctn Container) (interface{}, error) {
+		return "obj", nil
+	}).SetName("o1")
+
+	b.Add(def)
+	b.Add(NewDef(func(ctn Container) (interface{}, error) {
+		return "obj", nil
+	}).SetName("o2").SetScope(Request))
+
+	b.Transform(func(def Def) Def {
+		if def.Scope != App {
+			return def
+		}
+		build := def.Build
+		def.Build = func(ctn Container) (interface{}, error) {
+			obj, err := build(ctn)
+			return obj.(string) + "-traced", err
+		}
+		return def
+	})
+	b.Transform(func(def Def) Def {
+		def.Tags = append(def.Tags, Tag{Name: "transformed"})
+		return def
+	})
+
+	app, err := b.Build()
+	require.Nil(t, err)
+	require.Equal(t, "obj-traced", app.Get("o1"))
+	require.Equal(t, "obj-traced", app.Get(def))
+	require.Equal(t, []Tag{{Name: "transformed"}}, def.Tags)
+	require.Equal(t, []Tag{{Name: "transformed"}}, app.Definitions()["o2"].Tags)
+
+	request, _ := app.SubContainer()
+	require.Equal(t, "obj", request.Get("o2"))
+}
+
+func TestEnhancedBuilderTransformErrors(t *testing.T) {
+	newBuilder := func(transform func(def Def) Def) *EnhancedBuilder {
+		b, _ := NewEnhancedBuilder()
+		b.Add(NewDef(func(ctn Container) (interface{}, error) {
+			return "obj", nil
+		}).SetName("o1"))
+		b.Transform(transform)
+		return b
+	}
+
+	_, err := newBuilder(func(def Def) Def {
+		def.Name = "renamed"
+		return def
+	}).Build()
+	require.NotNil(t, err)
+
+	_, err = newBuilder(func(def Def) Def {
+		def.Scope = "undefined"
+		return def
+	}).Build()
+	require.NotNil(t, err)
+
+	_, err = newBuilder(func(def Def) Def {
+		def.Build = nil
+		return def
+	}).Build()
+	require.NotNil(t, err)
+}
+
+func TestEnhancedBuilderBuildUnsharedAssertions(t *testing.T) {
+	newBuilder := func() *EnhancedBuilder {
+		b, _ := NewEnhancedBuilder()
+		b.Add(NewDef(func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		}).SetName("pool"))
+		b.Add(NewDef(func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		}).SetName("transaction").SetUnshared(true))
+		return b
+	}
+
+	_, err := newBuilder().Build(WithForbidUnshared("pool", "unknown"), WithRequireUnshared("transaction"))
+	require.Nil(t, err)
+
+	_, err = newBuilder().Build(WithForbidUnshared("transaction"))
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "`transaction`")
+
+	_, err = newBuilder().Build(WithRequireUnshared("pool"))
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "`pool`")
+}
+
+func TestEnhancedBuilderBuildWithInferScopes(t *testing.T) {
+	buildFunc := func(ctn Container) (interface{}, error) { return nil, nil }
+
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(NewDef(buildFunc).SetName("handler").SetDependsOn("service", "config"))
+	b.Add(NewDef(buildFunc).SetName("service").SetDependsOn("session", "config"))
+	b.Add(NewDef(buildFunc).SetName("session").SetScope(Request))
+	b.Add(NewDef(buildFunc).SetName("config"))
+	b.Add(NewDef(buildFunc).SetName("logger").SetScope(SubRequest).SetDependsOn("config"))
+
+	app, err := b.Build(WithInferScopes())
+	require.Nil(t, err)
+
+	defs := app.Definitions()
+	require.Equal(t, Request, defs["handler"].Scope)
+	require.Equal(t, Request, defs["service"].Scope)
+	require.Equal(t, Request, defs["session"].Scope)
+	require.Equal(t, App, defs["config"].Scope)
+	require.Equal(t, SubRequest, defs["logger"].Scope)
+
+	// Without the option, the scope is not inferred.
+	// The definition stays in the most generic scope, so it can not depend on a request object.
+	b, _ = NewEnhancedBuilder()
+	b.Add(NewDef(buildFunc).SetName("service").SetDependsOn("session"))
+	b.Add(NewDef(buildFunc).SetName("session").SetScope(Request))
+
+	_, err = b.Build()
+	require.NotNil(t, err)
+
+	// The dependencies are also checked without the option.
+	b, _ = NewEnhancedBuilder()
+	b.Add(NewDef(buildFunc).SetName("service").SetDependsOn("undefined"))
+
+	_, err = b.Build()
+	require.NotNil(t, err)
+
+	b, _ = NewEnhancedBuilder()
+	b.Add(NewDef(buildFunc).SetName("service").SetScope(Request).SetDependsOn("config", "config-alias"))
+	b.Add(NewDef(buildFunc).SetName("config"))
+	b.Alias("config-alias", "config")
+
+	_, err = b.Build()
+	require.Nil(t, err)
+
+	// Declared scope wider than a dependency.
+	b, _ = NewEnhancedBuilder()
+	b.Add(NewDef(buildFunc).SetName("service").SetScope(App).SetDependsOn("session"))
+	b.Add(NewDef(buildFunc).SetName("session").SetScope(Request))
+
+	_, err = b.Build(WithInferScopes())
+	require.NotNil(t, err)
+
+	// Undefined dependency.
+	b, _ = NewEnhancedBuilder()
+	b.Add(NewDef(buildFunc).SetName("service").SetDependsOn("undefined"))
+
+	_, err = b.Build(WithInferScopes())
+	require.NotNil(t, err)
+
+	// Cycle.
+	b, _ = NewEnhancedBuilder()
+	b.Add(NewDef(buildFunc).SetName("a").SetDependsOn("b"))
+	b.Add(NewDef(buildFunc).SetName("b").SetDependsOn("a"))
+
+	_, err = b.Build(WithInferScopes())
+	require.NotNil(t, err)
+}
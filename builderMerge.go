@@ -0,0 +1,84 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Merge adds the definitions of another EnhancedBuilder to this one.
+// It allows to split the definitions of an application in several modules,
+// each module having its own EnhancedBuilder.
+//
+// The definitions are added in the order they were added to the other builder,
+// after the definitions already added to this builder.
+// As with Add, a definition replaces the definition with the same name that was already added to this builder.
+// The generated names of the definitions without name are generated again to avoid conflicts.
+//
+// The two builders must have the same scopes.
+// Only the definitions are merged. The other settings of the other builder,
+// like its aliases, its decorators or its hooks, are ignored.
+//
+// The definition pointers given to the Add method of the other builder are bound to the Container
+// generated by this builder. The other builder should not be built.
+func (b *EnhancedBuilder) Merge(other *EnhancedBuilder) error {
+	if other == nil {
+		return errors.New("could not merge a nil builder")
+	}
+
+	if len(b.scopes) == 0 || len(other.scopes) == 0 {
+		return errors.New("the builder was not created with NewEnhancedBuilder")
+	}
+
+	if !equalScopes(b.scopes, other.scopes) {
+		return fmt.Errorf("could not merge the builders because their scopes are different: %v and %v", b.scopes, other.scopes)
+	}
+
+	names := make([]string, 0, len(other.definitions))
+	for name := range other.definitions {
+		if _, ok := b.aliases[name]; ok {
+			return fmt.Errorf("could not merge the builders because the definition name `%s` is already used by an alias", name)
+		}
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		return other.insertionOrder[names[i]] < other.insertionOrder[names[j]]
+	})
+
+	for _, name := range names {
+		def := other.definitions[name]
+		def = def.deepCopy()
+
+		if strings.HasPrefix(def.Name, generatedNamePrefix) {
+			def.Name = generatedNamePrefix + strconv.Itoa(b.numAdded)
+		}
+
+		b.definitions[def.Name] = def
+		b.bindings[def.Name] = other.bindings[name]
+		b.insertionOrder[def.Name] = b.numAdded
+		b.numAdded++
+		for _, registration := range other.registrations[name] {
+			b.registrations[def.Name] = append(b.registrations[def.Name], registration+" in a merged builder")
+		}
+	}
+
+	return nil
+}
+
+// equalScopes returns true if the two scope lists contain the same scopes in the same order.
+func equalScopes(a, b ScopeList) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
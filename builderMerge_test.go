@@ -0,0 +1,75 @@
+package di
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnhancedBuilderMerge(t *testing.T) {
+	handlerType := reflect.TypeOf((*mockHandler)(nil)).Elem()
+
+	newHandlerDef := func(name, value string) *Def {
+		return &Def{
+			Name: name,
+			Is:   []reflect.Type{handlerType},
+			Build: func(ctn Container) (interface{}, error) {
+				return &mockHandlerImpl{name: value}, nil
+			},
+		}
+	}
+
+	b, _ := NewEnhancedBuilder()
+	b.Add(newHandlerDef("a", "a"))
+	b.Add(newHandlerDef("b", "b"))
+
+	module, _ := NewEnhancedBuilder()
+	cDef := newHandlerDef("c", "c")
+	module.Add(cDef)
+	module.Add(newHandlerDef("a", "module-a"))
+	module.Add(newHandlerDef("", "generated"))
+
+	require.Nil(t, b.Merge(module))
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	require.Equal(t, &mockHandlerImpl{name: "module-a"}, app.Get("a"))
+	require.Equal(t, &mockHandlerImpl{name: "c"}, app.Get(cDef))
+	require.Len(t, app.Definitions(), 4)
+
+	// The definitions of the other builder are inserted after the existing ones.
+	handlers, err := app.GetAllForType(handlerType)
+	require.Nil(t, err)
+	require.Equal(t, []interface{}{
+		&mockHandlerImpl{name: "b"},
+		&mockHandlerImpl{name: "c"},
+		&mockHandlerImpl{name: "module-a"},
+		&mockHandlerImpl{name: "generated"},
+	}, handlers)
+
+	require.Equal(t, []Conflict{{
+		Key:         "a",
+		Definitions: []string{"`a` added in position 1", "`a` added in position 2 in a merged builder"},
+	}}, b.Conflicts())
+}
+
+func TestEnhancedBuilderMergeErrors(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	require.NotNil(t, b.Merge(nil))
+	require.NotNil(t, b.Merge(&EnhancedBuilder{}))
+	require.NotNil(t, (&EnhancedBuilder{}).Merge(b))
+
+	other, _ := NewEnhancedBuilder("app", "request")
+	require.NotNil(t, b.Merge(other), "the scopes are different")
+
+	b.Add(&Def{Name: "target", Build: func(ctn Container) (interface{}, error) { return nil, nil }})
+	b.Alias("alias", "target")
+
+	module, _ := NewEnhancedBuilder()
+	module.Add(&Def{Name: "alias", Build: func(ctn Container) (interface{}, error) { return nil, nil }})
+	require.NotNil(t, b.Merge(module), "the name is used by an alias")
+	require.False(t, b.NameIsDefined("alias"))
+}
@@ -0,0 +1,179 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Validate checks the dependencies between the definitions registered at this point.
+// It creates a temporary Container, and retrieves the object of each definition from a Container of the most specific scope.
+// The calls to Get, SafeGet and Fill made by the Build functions are recorded, and Validate returns an error if:
+//   - a Build function requests a name or a type that is not defined
+//   - a Build function requests an object of a more specific scope than the scope of its definition
+//
+// All the problems are reported in the returned error.
+// Other build errors are ignored, as they may not be caused by a wiring problem.
+//
+// Validate is a best-effort check. It calls the Build functions, so their side effects happen,
+// and a dependency is only detected if the Build function actually requests it.
+// The temporary Container is deleted, and the objects closed, before Validate returns.
+// The definitions are not bound to the temporary Container, so the Build method can still be called afterwards.
+// The options are the ones of the Build method.
+func (b *EnhancedBuilder) Validate(opts ...BuildOption) error {
+	if len(b.scopes) == 0 {
+		return fmt.Errorf("the builder was not created with NewEnhancedBuilder")
+	}
+
+	recorder := b.newDryRunRecorder()
+
+	if _, err := b.dryRun(recorder, opts); err != nil {
+		return err
+	}
+
+	return recorder.build()
+}
+
+// newDryRunRecorder creates a dryRunRecorder for the definitions of the builder.
+func (b *EnhancedBuilder) newDryRunRecorder() *dryRunRecorder {
+	recorder := &dryRunRecorder{
+		names:  make(map[*Def]string, len(b.bindings)),
+		errors: &multiErrBuilder{},
+		seen:   map[string]struct{}{},
+	}
+	for name, def := range b.bindings {
+		recorder.names[def] = name
+	}
+	return recorder
+}
+
+// dryRun builds a temporary Container from a copy of the builder,
+// and retrieves the object of each definition from a Container of the most specific scope.
+// The requests made by the Build functions are given to the recorder.
+// It returns the names of the definitions of the temporary Container, ordered by index.
+func (b *EnhancedBuilder) dryRun(recorder *dryRunRecorder, opts []BuildOption) ([]string, error) {
+	opts = append(opts, func(o *buildOptions) {
+		o.dryRun = recorder
+	})
+
+	ctn, err := b.dryRunCopy().Build(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer ctn.DeleteWithSubContainers()
+
+	deepest := ctn
+	for deepest.core.scopeLevel < len(deepest.core.scopes)-1 {
+		if deepest, err = deepest.SubContainer(); err != nil {
+			return nil, err
+		}
+	}
+
+	for index := range deepest.core.definitions {
+		deepest.SafeGet(index)
+	}
+
+	return ctn.Names(), nil
+}
+
+// dryRunCopy returns a copy of the builder, with its own bindings.
+// The warning handlers, the hooks, the metrics, the logger and the object wrapper are not copied.
+func (b *EnhancedBuilder) dryRunCopy() *EnhancedBuilder {
+	c := b.Clone()
+
+	c.warningHandlers = []func(def Def, err error){}
+	c.buildHooks = []func(def Def, obj interface{}, d time.Duration){}
+	c.closeHooks = []func(def Def, err error){}
+	c.metrics = nil
+	c.objectWrapper = nil
+	c.logger = nil
+
+	return c
+}
+
+// dryRunRecorder records the problems found by the Validate method of the EnhancedBuilder.
+type dryRunRecorder struct {
+	m      sync.Mutex
+	names  map[*Def]string
+	errors *multiErrBuilder
+	seen   map[string]struct{}
+
+	// dependencies contains the definition indexes requested by each definition.
+	// It is only set by the CheckCycles method.
+	dependencies *graph
+}
+
+// record checks an object requested by the Container given to a Build function.
+// It returns the key to use to retrieve the object. The definitions of the original builder
+// are not bound to the temporary Container, so they are replaced by their names.
+func (r *dryRunRecorder) record(ctn Container, in interface{}) interface{} {
+	switch v := in.(type) {
+	case *Def:
+		if name, ok := r.names[v]; ok && !v.builderBound {
+			in = name
+		}
+	case Def:
+		if !v.builderBound && v.Name != "" {
+			in = v.Name
+		}
+	}
+
+	if len(ctn.buildStack) == 0 {
+		return in
+	}
+
+	caller := ctn.buildStack[len(ctn.buildStack)-1]
+	callerIndex, ok := ctn.core.indexesByName[caller]
+	if !ok {
+		return in
+	}
+
+	index, err := ctn.core.resolveIndex(in)
+	if err != nil {
+		if typ, ok := in.(reflect.Type); ok && typ.Kind() == reflect.Slice && len(ctn.core.indexesByType[typ.Elem()]) > 0 {
+			return in
+		}
+		r.add(fmt.Sprintf("`%s` requests `%s` which is not defined", caller, ctn.core.keyName(in)))
+		return in
+	}
+
+	if r.dependencies != nil {
+		r.m.Lock()
+		r.dependencies.AddEdge(callerIndex, index)
+		r.m.Unlock()
+	}
+
+	if ctn.core.definitionScopeLevels[index] > ctn.core.definitionScopeLevels[callerIndex] {
+		r.add(fmt.Sprintf(
+			"`%s` in scope `%s` requests `%s` in the more specific scope `%s`",
+			caller,
+			ctn.core.definitions[callerIndex].Scope,
+			ctn.core.definitions[index].Name,
+			ctn.core.definitions[index].Scope,
+		))
+	}
+
+	return in
+}
+
+// add records a problem, unless it has already been recorded.
+func (r *dryRunRecorder) add(problem string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if _, ok := r.seen[problem]; ok {
+		return
+	}
+
+	r.seen[problem] = struct{}{}
+	r.errors.Add(fmt.Errorf("%s", problem))
+}
+
+// build returns the recorded problems as a single error.
+func (r *dryRunRecorder) build() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.errors.Build()
+}
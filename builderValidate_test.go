@@ -0,0 +1,91 @@
+package di
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnhancedBuilderValidate(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	numClosed := 0
+
+	config := &Def{
+		Name:  "config",
+		Build: func(ctn Container) (interface{}, error) { return "config", nil },
+		Close: func(obj interface{}) error {
+			numClosed++
+			return nil
+		},
+	}
+	require.Nil(t, b.Add(config))
+
+	require.Nil(t, b.Add(&Def{
+		Name: "uses-def",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.SafeGet(config)
+		},
+	}))
+	require.Nil(t, b.Add(&Def{
+		Name: "missing-dependency",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.SafeGet("missing")
+		},
+	}))
+	require.Nil(t, b.Add(&Def{
+		Name: "scope-violation",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.SafeGet("request")
+		},
+	}))
+	require.Nil(t, b.Add(&Def{
+		Name:  "request",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.Get("config"), nil
+		},
+	}))
+	require.Nil(t, b.Add(&Def{
+		Name: "failing",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, errors.New("build error")
+		},
+	}))
+
+	err := b.Validate()
+	require.NotNil(t, err)
+	require.Equal(
+		t,
+		"`missing-dependency` requests `missing` which is not defined AND "+
+			"`scope-violation` in scope `app` requests `request` in the more specific scope `request`",
+		err.Error(),
+	)
+	require.Equal(t, 1, numClosed, "the objects of the temporary container should be closed")
+
+	// The definitions are not bound by Validate.
+	require.Equal(t, -1, config.Index())
+
+	app, err := b.Build()
+	require.Nil(t, err)
+	require.Equal(t, "config", app.Get(config))
+}
+
+func TestEnhancedBuilderValidateWithoutProblem(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	require.Nil(t, b.Add(&Def{
+		Name:  "config",
+		Build: func(ctn Container) (interface{}, error) { return "config", nil },
+	}))
+	require.Nil(t, b.Add(&Def{
+		Name:  "request",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.Get("config"), nil
+		},
+	}))
+
+	require.Nil(t, b.Validate())
+}
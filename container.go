@@ -1,8 +1,11 @@
 package di
 
 import (
+	"context"
+	"fmt"
 	"reflect"
 	"sync"
+	"sync/atomic"
 )
 
 // Container represents a dependency injection container.
@@ -27,6 +30,27 @@ type Container struct {
 	// of a definition, this is in fact a new Container.
 	// Is has the same core but an updated builtList field.
 	builtList []int
+
+	// buildStack contains the names of all the definitions that are being built by this Container.
+	// Contrary to builtList, it is not reset when the scope changes.
+	// It includes the definitions being built by the parents,
+	// and by the containers that created this Container to use the unscoped getters.
+	// Names are used instead of indexes because a sub-container can have more definitions than its parents.
+	buildStack []string
+
+	// progress is shared by all the containers of a chain of builds.
+	// It contains the build stack of the object that is currently being built in the chain.
+	// It is only set if the builds are tracked (check WithBuildTracking), and it can be nil.
+	progress *buildProgress
+
+	// warmUp records the objects built while WarmAll retrieves an object,
+	// so that they can be closed if the retrieval fails. It is nil outside of WarmAll.
+	warmUp *warmUpRecorder
+
+	// ctx is the context given to WithContext. It can be nil.
+	// It is stored in the Container and not in the core, so that it is only visible
+	// to the Build functions called from this Container.
+	ctx context.Context
 }
 
 // containerCore contains the data of a Container.
@@ -36,6 +60,17 @@ type containerCore struct {
 	m      sync.RWMutex
 	closed bool
 
+	// done is closed when the Container is deleted.
+	done chan struct{}
+
+	// name is the label given to the Container with WithName.
+	// It is stored in an atomic.Value because it is used in error messages
+	// that can be generated while the lock is held.
+	name atomic.Value
+
+	// config is shared by all the containers created by the same builder.
+	config *containerConfig
+
 	// scopes
 	scopes     ScopeList
 	scopeLevel int
@@ -51,21 +86,47 @@ type containerCore struct {
 	indexesByType         map[reflect.Type][]int
 	definitions           []Def
 	definitionScopeLevels []int
-	objects               []interface{}
+	objects               []atomic.Value
+	cleanups              []func() error
 	isBuilt               []int32
 	building              []*buildingChan
 
-	// unshared objects are stored separately in unshared.
-	// The index of their definition is stored in unsharedIndex at the same position as in unshared.
-	unshared      []interface{}
-	unsharedIndex []int
+	// unshared objects are stored separately in unshared,
+	// along with the objects created with GetOrStore.
+	unshared []unsharedObject
+
+	// stored contains the position in unshared of the objects created with GetOrStore.
+	// storing contains the keys of the objects that are being created with GetOrStore.
+	stored  map[string]int
+	storing map[string]*buildingChan
+
+	// roundRobin contains the counters used by GetRoundRobin for each type.
+	roundRobin map[reflect.Type]*uint64
+
+	// deprecationWarned contains the indexes of the deprecated definitions
+	// that have already triggered a warning in this container. It is nil until the first warning.
+	deprecationWarned map[int]struct{}
+
+	// scopeLeaks contains the pairs of definition indexes [requester, dependency]
+	// of the objects of this container retrieved during the build of an object of a more generic scope.
+	// scopeLeaksFound is used to record each pair only once. They are nil until the first leak.
+	scopeLeaks      [][2]int
+	scopeLeaksFound map[[2]int]struct{}
+
+	// draining is set to 1 by Drain. It is only updated with the lock held,
+	// but it can be read atomically from the sub-containers.
+	// numBuilds is the number of objects being built by this container,
+	// and buildsDone is closed when numBuilds goes back to 0.
+	draining   int32
+	numBuilds  int
+	buildsDone chan struct{}
 
 	// dependencies is a graph that allows to determine
 	// in which order the definitions should be closed.
 	// Each vertex is an index. If >= 0 it is the index of a shared object.
 	// If < 0, it is the opposite of the index in unshared minus 1.
 	// For example the first object in unshared is at position 0, so its vertice is -0-1=-1.
-	dependencies *graph
+	dependencies dependencyTracker
 }
 
 // Definitions returns the map of the available definitions ordered by name.
@@ -80,12 +141,51 @@ func (ctn Container) Definitions() map[string]Def {
 	return defs
 }
 
+// Names returns the names of the available definitions,
+// in the order the definitions were inserted in the builder.
+// Contrary to Definitions, the order is deterministic and no map is allocated.
+func (ctn Container) Names() []string {
+	names := make([]string, len(ctn.core.definitions))
+
+	for i, def := range ctn.core.definitions {
+		names[i] = def.Name
+	}
+
+	return names
+}
+
 // NameIsDefined returns true if there is a definition for the given name.
 func (ctn Container) NameIsDefined(name string) bool {
 	_, ok := ctn.core.indexesByName[name]
 	return ok
 }
 
+// ResolveName returns the name of the definition that is retrieved with the given name.
+// It is the name itself if it is the name of a definition,
+// or the name of the target definition if it is an alias.
+// It returns an error if there is no definition for this name.
+func (ctn Container) ResolveName(name string) (string, error) {
+	index, ok := ctn.core.indexesByName[name]
+	if !ok {
+		return "", fmt.Errorf("could not resolve `%s` because %w", name, ErrNotDefined)
+	}
+	return ctn.core.definitions[index].Name, nil
+}
+
+// Aliases returns the aliases of the definitions, registered with the Alias method of the EnhancedBuilder.
+// The keys are the aliases and the values are the names of their target definitions.
+func (ctn Container) Aliases() map[string]string {
+	aliases := map[string]string{}
+
+	for name, index := range ctn.core.indexesByName {
+		if target := ctn.core.definitions[index].Name; target != name {
+			aliases[name] = target
+		}
+	}
+
+	return aliases
+}
+
 // TypeIsDefined returns true if there is a definition for the given type.
 // Types are declared in the Is field of a definition.
 func (ctn Container) TypeIsDefined(typ reflect.Type) bool {
@@ -106,6 +206,171 @@ func (ctn Container) DefinitionsForType(typ reflect.Type) []Def {
 	return defs
 }
 
+// TypeMatch describes a definition matching a type. It is returned by DefinitionsForTypeDetailed.
+type TypeMatch struct {
+	Def Def
+	// Reachable is true if the object can be retrieved from the Container,
+	// meaning that its scope is the Container scope or the scope of one of its parents.
+	Reachable bool
+	// Scope is the scope of the definition.
+	Scope string
+}
+
+// DefinitionsForTypeDetailed works like DefinitionsForType,
+// but it also indicates if each definition can be reached from the Container.
+// The definitions are in the order they were inserted in the builder.
+func (ctn Container) DefinitionsForTypeDetailed(typ reflect.Type) []TypeMatch {
+	indexes := ctn.core.indexesByType[typ]
+	matches := make([]TypeMatch, 0, len(indexes))
+
+	for _, index := range indexes {
+		matches = append(matches, TypeMatch{
+			Def:       ctn.core.definitions[index],
+			Reachable: ctn.core.definitionScopeLevels[index] <= ctn.core.scopeLevel,
+			Scope:     ctn.core.definitions[index].Scope,
+		})
+	}
+
+	return matches
+}
+
+// ClosableDefinitions returns the names of the definitions whose objects may need to be closed
+// when the Container is deleted, in the order they were inserted in the builder.
+// These are the definitions with a Close function or a BuildWithCleanup function.
+func (ctn Container) ClosableDefinitions() []string {
+	names := []string{}
+
+	for _, def := range ctn.core.definitions {
+		if def.Close != nil || def.BuildWithCleanup != nil {
+			names = append(names, def.Name)
+		}
+	}
+
+	return names
+}
+
+// DefinitionScopeLevel returns the level of the scope of a definition.
+// The level is the position of the scope in the list returned by Scopes.
+// The definition can be given the same way as in SafeGet:
+// by name, definition, definition pointer, index or type.
+// It returns an error if there is no such definition.
+func (ctn Container) DefinitionScopeLevel(in interface{}) (int, error) {
+	index, err := ctn.core.resolveIndex(in)
+	if err != nil {
+		return 0, err
+	}
+	return ctn.core.definitionScopeLevels[index], nil
+}
+
+// ScopeOf returns the scope of the definition with the given name,
+// and false if there is no such definition.
+// An object can only be retrieved from a Container in this scope or in a more specific scope.
+func (ctn Container) ScopeOf(name string) (string, bool) {
+	index, ok := ctn.core.indexesByName[name]
+	if !ok {
+		return "", false
+	}
+	return ctn.core.definitions[index].Scope, true
+}
+
+// ScopeLevelOf returns the level of the scope of the definition with the given name,
+// and false if there is no such definition.
+// The level is the position of the scope in the list returned by Scopes.
+// Contrary to DefinitionScopeLevel, only names are accepted.
+func (ctn Container) ScopeLevelOf(name string) (int, bool) {
+	index, ok := ctn.core.indexesByName[name]
+	if !ok {
+		return 0, false
+	}
+	return ctn.core.definitionScopeLevels[index], true
+}
+
+// ScopeLevels returns a map with the scope names as keys and their levels as values.
+// The level is the position of the scope in the list returned by Scopes.
+func (ctn Container) ScopeLevels() map[string]int {
+	levels := make(map[string]int, len(ctn.core.scopes))
+	for level, scope := range ctn.core.scopes {
+		levels[scope] = level
+	}
+	return levels
+}
+
+// resolveIndex returns the index of the definition matching the input,
+// with the same rules as SafeGet, except for slice types.
+func (core *containerCore) resolveIndex(in interface{}) (int, error) {
+	var index int
+
+	switch v := in.(type) {
+	case int:
+		index = v
+	case Def:
+		index = v.Index()
+	case *Def:
+		index = v.Index()
+	case string:
+		var ok bool
+		index, ok = core.indexesByName[v]
+		if !ok {
+			return 0, fmt.Errorf("could not find `%s`%s because %w", v, core.nameSuffix(), ErrNotDefined)
+		}
+	case reflect.Type:
+		indexes := core.indexesByType[v]
+		if len(indexes) == 0 {
+			return 0, fmt.Errorf("could not find type `%s`%s because %w", v, core.nameSuffix(), ErrNotDefined)
+		}
+		var err error
+		if index, err = core.selectTypeIndex(v, indexes); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, fmt.Errorf("could not find a definition for `%v` of type %T", in, in)
+	}
+
+	if index < 0 || index >= len(core.definitionScopeLevels) {
+		return 0, fmt.Errorf("could not find index `%d`%s because %w", index, core.nameSuffix(), ErrNotDefined)
+	}
+
+	return index, nil
+}
+
+// keyName returns the name of the definition matching one of the parameters accepted by SafeGet.
+// The types that do not match a single definition (e.g. slice types) are returned as they are.
+func (core *containerCore) keyName(in interface{}) string {
+	if typ, ok := in.(reflect.Type); ok && len(core.indexesByType[typ]) == 0 {
+		return typ.String()
+	}
+
+	if index, err := core.resolveIndex(in); err == nil {
+		return core.definitions[index].Name
+	}
+
+	return fmt.Sprint(in)
+}
+
+// WithName gives a name to the Container. The Container is returned to allow chaining.
+// The name is only used to identify the Container in error messages and logs.
+// It is not inherited by the sub-containers. By default a Container does not have a name.
+func (ctn Container) WithName(name string) Container {
+	ctn.core.name.Store(name)
+	return ctn
+}
+
+// Name returns the name given to the Container with WithName.
+// It returns an empty string if the Container does not have a name.
+func (ctn Container) Name() string {
+	name, _ := ctn.core.name.Load().(string)
+	return name
+}
+
+// nameSuffix returns a string that can be added to error messages to identify the Container.
+// It is empty if the Container does not have a name.
+func (core *containerCore) nameSuffix() string {
+	if name, _ := core.name.Load().(string); name != "" {
+		return " in container `" + name + "`"
+	}
+	return ""
+}
+
 // Scope returns the Container scope.
 func (ctn Container) Scope() string {
 	return ctn.core.scopes[ctn.core.scopeLevel]
@@ -116,6 +381,24 @@ func (ctn Container) Scopes() []string {
 	return ctn.core.scopes.Copy()
 }
 
+// UsedScopes returns the scopes that are used by at least one definition.
+// The scopes are ordered from the most generic to the most specific, as in Scopes.
+func (ctn Container) UsedScopes() []string {
+	used := make([]bool, len(ctn.core.scopes))
+	for _, level := range ctn.core.definitionScopeLevels {
+		used[level] = true
+	}
+
+	scopes := []string{}
+	for level, scope := range ctn.core.scopes {
+		if used[level] {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return scopes
+}
+
 // ParentScopes returns the list of scopes that are more generic than the Container scope.
 func (ctn Container) ParentScopes() []string {
 	return ctn.core.scopes.ParentScopes(ctn.Scope())
@@ -126,33 +409,181 @@ func (ctn Container) SubScopes() []string {
 	return ctn.core.scopes.SubScopes(ctn.Scope())
 }
 
+// storageCore returns the core that stores the object of the definition at the given index
+// when it is retrieved from this core. It is the core in the scope of the definition,
+// unless the definition has a BuildForScope function for the scope of this core or one of its parents in between.
+// It returns nil if the definition scope is not reachable from this core.
+func (core *containerCore) storageCore(index int) *containerCore {
+	level := core.definitionScopeLevels[index]
+
+	if builds := core.definitions[index].BuildForScope; len(builds) > 0 {
+		for c := core; c != nil && c.scopeLevel >= level; c = c.parent {
+			if _, ok := builds[c.scopes[c.scopeLevel]]; ok {
+				return c
+			}
+		}
+	}
+
+	for c := core; c != nil; c = c.parent {
+		if c.scopeLevel == level {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// storedObject is the type of the values stored in the objects field of a containerCore.
+// The objects are stored in atomic values, so that they can be replaced with ReplaceObject
+// while they are retrieved without holding the lock. An atomic.Value can only hold values of the same type.
+type storedObject struct {
+	obj interface{}
+	// replaced is true if the object was set with ReplaceObject instead of being built.
+	replaced bool
+}
+
+// object returns the object stored at the given index, or nil if there is none.
+func (core *containerCore) object(index int) interface{} {
+	stored, _ := core.objects[index].Load().(storedObject)
+	return stored.obj
+}
+
+// setObject stores an object at the given index.
+func (core *containerCore) setObject(index int, obj interface{}) {
+	core.objects[index].Store(storedObject{obj: obj})
+}
+
+// setReplacedObject stores an object given to ReplaceObject at the given index.
+func (core *containerCore) setReplacedObject(index int, obj interface{}) {
+	core.objects[index].Store(storedObject{obj: obj, replaced: true})
+}
+
+// objectCloseFunc returns the function that should be used to close the shared object stored at the given index.
+// A replaced object was not built by the Container, so it is never put in the pool of its definition.
+func (core *containerCore) objectCloseFunc(ctx context.Context, index int) func(obj interface{}) error {
+	def := core.definitions[index]
+
+	if stored, _ := core.objects[index].Load().(storedObject); stored.replaced {
+		return def.closeFuncWithContext(ctx, core.cleanups[index])
+	}
+
+	return core.config.sharedCloseFunc(ctx, def, core.cleanups[index])
+}
+
+// unsharedObject is an object that is not stored at the index of its definition,
+// but that still needs to be closed when the container is deleted.
+type unsharedObject struct {
+	obj interface{}
+	// index is the index of the definition used to build the object,
+	// or -1 if the object was not built from a definition.
+	index int
+	name  string
+	close func(obj interface{}) error
+	// cleanup is the cleanup function returned by BuildWithCleanup.
+	// It is already included in close, but it is needed to call CloseWithContext with another context.
+	cleanup func() error
+}
+
+// newRootCore creates the core of a Container in the most generic scope.
+func newRootCore(
+	scopes ScopeList,
+	definitions []Def,
+	indexesByName map[string]int,
+	indexesByType map[reflect.Type][]int,
+	definitionScopeLevels []int,
+	config *containerConfig,
+) *containerCore {
+	return &containerCore{
+		closed: false,
+		done:   make(chan struct{}),
+		config: config,
+
+		scopes:     scopes,
+		scopeLevel: 0,
+
+		parent:          nil,
+		children:        map[*containerCore]struct{}{},
+		unscopedChild:   nil,
+		deleteIfNoChild: false,
+
+		indexesByName:         indexesByName,
+		indexesByType:         indexesByType,
+		definitions:           definitions,
+		definitionScopeLevels: definitionScopeLevels,
+		objects:               make([]atomic.Value, len(definitions)),
+		cleanups:              make([]func() error, len(definitions)),
+		isBuilt:               make([]int32, len(definitions)),
+		building:              make([]*buildingChan, len(definitions)),
+
+		unshared: []unsharedObject{},
+		stored:   map[string]int{},
+		storing:  map[string]*buildingChan{},
+
+		roundRobin: map[reflect.Type]*uint64{},
+
+		dependencies: config.newDependencyTracker(),
+	}
+}
+
+// newChildCore creates the core of a Container in the next sub-scope of the given core.
+// It does not register the new core as a child of the parent core.
+func newChildCore(parent *containerCore) *containerCore {
+	core := newRootCore(
+		parent.scopes,
+		parent.definitions,
+		parent.indexesByName,
+		parent.indexesByType,
+		parent.definitionScopeLevels,
+		parent.config,
+	)
+
+	core.scopeLevel = parent.scopeLevel + 1
+	core.parent = parent
+
+	return core
+}
+
 // newClosedContainer returns a closed container. It is not usable and is returned when there is an error.
 func newClosedContainer() Container {
+	core := newRootCore(
+		[]string{},
+		[]Def{},
+		map[string]int{},
+		map[reflect.Type][]int{},
+		[]int{},
+		newContainerConfig(newBuildOptions(nil)),
+	)
+
+	core.closed = true
+	close(core.done)
+
 	return Container{
-		core: &containerCore{
-			closed: true,
-
-			scopes:     []string{},
-			scopeLevel: 0,
-
-			parent:          nil,
-			children:        map[*containerCore]struct{}{},
-			unscopedChild:   nil,
-			deleteIfNoChild: false,
-
-			indexesByName:         map[string]int{},
-			indexesByType:         map[reflect.Type][]int{},
-			definitions:           []Def{},
-			objects:               []interface{}{},
-			definitionScopeLevels: []int{},
-			isBuilt:               []int32{},
-			building:              []*buildingChan{},
-
-			unshared:      []interface{}{},
-			unsharedIndex: []int{},
-
-			dependencies: newGraph(),
-		},
+		core:      core,
 		builtList: make([]int, 0, 10),
 	}
 }
+
+// UnsharedInstances returns the unshared objects built by this Container for the given definition name.
+// The objects are returned in the order they were built.
+// Only the objects that are tracked by the Container are returned.
+// That means only the objects whose definition has a Close function,
+// because the other ones are not kept in the Container after they are built.
+// The objects of the parent containers and sub-containers are not included.
+func (ctn Container) UnsharedInstances(name string) []interface{} {
+	objects := []interface{}{}
+
+	index, ok := ctn.core.indexesByName[name]
+	if !ok {
+		return objects
+	}
+
+	ctn.core.m.RLock()
+	for _, u := range ctn.core.unshared {
+		if u.index == index {
+			objects = append(objects, u.obj)
+		}
+	}
+	ctn.core.m.RUnlock()
+
+	return objects
+}
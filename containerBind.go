@@ -0,0 +1,85 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// bindStruct fills the fields of the struct pointed by ptr that have a `di` tag.
+// The tag can contain the name of the definition to use (`di:"name"`).
+// If it is empty (`di:""`), the field is filled by type, as with SafeGet(fieldType).
+// If the tag ends with ",optional" (`di:"name,optional"` or `di:",optional"`),
+// the field is left empty when there is no matching definition.
+func bindStruct(ctn Container, ptr reflect.Value) error {
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("could not bind `%s` because it is not a struct", ptr.Type())
+	}
+
+	obj := ptr.Elem()
+	typ := obj.Type()
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		tag, ok := field.Tag.Lookup("di")
+		if !ok {
+			continue
+		}
+
+		if field.PkgPath != "" {
+			return fmt.Errorf("could not bind `%s` because the field `%s` has a di tag but is not exported", typ, field.Name)
+		}
+
+		name := tag
+		optional := false
+
+		if strings.HasSuffix(tag, ",optional") {
+			name = strings.TrimSuffix(tag, ",optional")
+			optional = true
+		}
+
+		var in interface{} = name
+		if name == "" {
+			in = field.Type
+		}
+
+		if optional && !bindTargetIsDefined(ctn, in) {
+			continue
+		}
+
+		v, err := ctn.SafeGet(in)
+		if err != nil {
+			return fmt.Errorf("could not bind `%s` because the field `%s` could not be resolved: %w", typ, field.Name, err)
+		}
+
+		if v == nil {
+			continue
+		}
+
+		value := reflect.ValueOf(v)
+
+		if !value.Type().AssignableTo(field.Type) {
+			return fmt.Errorf(
+				"could not bind `%s` because the field `%s` is a `%s` and the object is a `%s`",
+				typ, field.Name, field.Type, value.Type(),
+			)
+		}
+
+		obj.Field(i).Set(value)
+	}
+
+	return nil
+}
+
+// bindTargetIsDefined returns true if there is a definition
+// for the name or the type used to fill a field in bindStruct.
+func bindTargetIsDefined(ctn Container, in interface{}) bool {
+	switch v := in.(type) {
+	case string:
+		return ctn.NameIsDefined(v)
+	case reflect.Type:
+		return ctn.TypeIsDefined(v) || (v.Kind() == reflect.Slice && ctn.TypeIsDefined(v.Elem()))
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+//go:build go1.18
+// +build go1.18
+
+package di
+
+import "reflect"
+
+// Bind allocates a new T and fills its fields that have a `di` tag with objects from the Container.
+// T must be a struct. The tag contains the name of the definition to use:
+//
+//	type Handler struct {
+//		DB     *sql.DB `di:"db"`         // filled with ctn.SafeGet("db")
+//		Logger Logger  `di:""`           // filled with ctn.SafeGet(reflect.TypeOf(Logger))
+//		Cache  Cache   `di:",optional"`  // left empty if Cache is not defined
+//	}
+//
+// The fields with a `di` tag must be exported.
+// The fields without a `di` tag are left empty.
+// Bind returns an error if a field can not be filled.
+func Bind[T any](ctn Container) (*T, error) {
+	obj := new(T)
+
+	if err := bindStruct(ctn, reflect.ValueOf(obj)); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// MustBind works like Bind, but it panics if the object can not be bound.
+// It is meant to be used at startup, when a missing dependency should be fatal.
+func MustBind[T any](ctn Container) *T {
+	obj, err := Bind[T](ctn)
+	if err != nil {
+		panic(err)
+	}
+	return obj
+}
@@ -0,0 +1,98 @@
+//go:build go1.18
+// +build go1.18
+
+package di
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockBound struct {
+	A        *mockA        `di:"a"`
+	B        *mockB        `di:""`
+	Handlers []mockHandler `di:""`
+	C        *mockC        `di:"c,optional"`
+	D        *mockD        `di:",optional"`
+	Untagged *mockA
+}
+
+type mockBoundUnexported struct {
+	a *mockA `di:"a"`
+}
+
+type mockBoundMissing struct {
+	C *mockC `di:"c"`
+}
+
+type mockBoundWrongType struct {
+	A *mockB `di:"a"`
+}
+
+func newBindTestContainer(t *testing.T) Container {
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name: "a",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{SField: "a"}, nil
+		},
+	})
+	b.Add(&Def{
+		Name: "b",
+		Is:   NewIs(&mockB{}),
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockB{}, nil
+		},
+	})
+	b.Add(&Def{
+		Name: "h",
+		Is:   []reflect.Type{reflect.TypeOf((*mockHandler)(nil)).Elem()},
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockHandlerImpl{name: "h"}, nil
+		},
+	})
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	return app
+}
+
+func TestBind(t *testing.T) {
+	app := newBindTestContainer(t)
+
+	obj, err := Bind[mockBound](app)
+	require.Nil(t, err)
+	require.True(t, obj.A == app.Get("a"))
+	require.True(t, obj.B == app.Get("b"))
+	require.Len(t, obj.Handlers, 1)
+	require.Nil(t, obj.C)
+	require.Nil(t, obj.D)
+	require.Nil(t, obj.Untagged)
+
+	_, err = Bind[mockBoundUnexported](app)
+	require.NotNil(t, err)
+
+	_, err = Bind[mockBoundMissing](app)
+	require.NotNil(t, err)
+
+	_, err = Bind[mockBoundWrongType](app)
+	require.NotNil(t, err)
+
+	_, err = Bind[int](app)
+	require.NotNil(t, err)
+}
+
+func TestMustBind(t *testing.T) {
+	app := newBindTestContainer(t)
+
+	obj := MustBind[mockBound](app)
+	require.True(t, obj.A == app.Get("a"))
+
+	require.Panics(t, func() {
+		MustBind[mockBoundMissing](app)
+	})
+}
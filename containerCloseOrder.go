@@ -0,0 +1,63 @@
+package di
+
+import "fmt"
+
+// newCloseHints converts the CloseBefore and CloseAfter fields of the definitions
+// into pairs of definition indexes. In each pair, the first definition must be closed before the second one.
+// The names that do not match any definition are ignored.
+// It returns an error if the hints contain a cycle.
+func newCloseHints(definitions []Def, indexesByName map[string]int) ([][2]int, error) {
+	hints := [][2]int{}
+	g := newGraph()
+
+	for index, def := range definitions {
+		for _, name := range def.CloseBefore {
+			if other, ok := indexesByName[name]; ok {
+				hints = append(hints, [2]int{index, other})
+				g.AddEdge(index, other)
+			}
+		}
+		for _, name := range def.CloseAfter {
+			if other, ok := indexesByName[name]; ok {
+				hints = append(hints, [2]int{other, index})
+				g.AddEdge(other, index)
+			}
+		}
+	}
+
+	if _, err := g.TopologicalOrdering(); err != nil {
+		return nil, fmt.Errorf("could not order the Close functions because the CloseBefore and CloseAfter fields of the definitions contain a cycle: %w", err)
+	}
+
+	return hints, nil
+}
+
+// applyCloseHints adds the close hints to the dependencies of a detached core,
+// and returns the new order in which the objects should be closed.
+// The vertices are the ones returned by the TopologicalOrdering of the dependencies.
+// If the hints create a cycle with the dependencies, the vertices are returned unchanged with an error.
+func applyCloseHints(clone *containerCore, vertices []int) ([]int, error) {
+	verticesByDefinition := map[int][]int{}
+
+	for _, vertex := range vertices {
+		index := clone.vertexDefinitionIndex(vertex)
+		if index >= 0 {
+			verticesByDefinition[index] = append(verticesByDefinition[index], vertex)
+		}
+	}
+
+	for _, hint := range clone.config.closeHints {
+		for _, from := range verticesByDefinition[hint[0]] {
+			for _, to := range verticesByDefinition[hint[1]] {
+				clone.dependencies.AddEdge(from, to)
+			}
+		}
+	}
+
+	ordered, err := clone.dependencies.TopologicalOrdering()
+	if err != nil {
+		return vertices, fmt.Errorf("could not respect the CloseBefore and CloseAfter fields of the definitions because they conflict with the dependencies: %w", err)
+	}
+
+	return ordered, nil
+}
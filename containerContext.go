@@ -0,0 +1,29 @@
+package di
+
+import "context"
+
+// WithContext returns a copy of the Container holding the given context.
+// The copy uses the same objects and the same scope as this Container, like ForkView,
+// but the Container given to the Build functions of the objects retrieved with the copy
+// also holds the context. The Build functions can use the Context method to retrieve it,
+// for example to stop dialing a network when the deadline of the current http request is exceeded.
+//
+// The context is only held by the returned Container, not by the Container it was created from.
+// It is kept by ForkView and by the unscoped getters, but not by the methods returning other containers,
+// like SubContainer or Parent.
+//
+// Be careful with the shared objects: they are built with the context of the Container
+// that retrieved them first, so they should not keep this context after their Build function returns.
+func (ctn Container) WithContext(ctx context.Context) Container {
+	ctn.ctx = ctx
+	return ctn
+}
+
+// Context returns the context given to WithContext.
+// If WithContext was not used, it returns context.Background().
+func (ctn Container) Context() context.Context {
+	if ctn.ctx == nil {
+		return context.Background()
+	}
+	return ctn.ctx
+}
@@ -0,0 +1,53 @@
+package di
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type contextKey string
+
+func TestContainerWithContext(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	contextValue := func(ctn Container) (interface{}, error) {
+		v, _ := ctn.Context().Value(contextKey("key")).(string)
+		return v, nil
+	}
+
+	b.Add(&Def{Name: "app", Build: contextValue})
+	b.Add(&Def{
+		Name:  "request",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			v, _ := contextValue(ctn)
+			return v.(string) + "-" + ctn.Get("dependency").(string), nil
+		},
+	})
+	b.Add(&Def{Name: "dependency", Scope: Request, Build: contextValue})
+	b.Add(&Def{Name: "unscoped", Scope: SubRequest, Build: contextValue})
+
+	app, _ := b.Build()
+	req, _ := app.SubContainer()
+
+	require.Equal(t, context.Background(), req.Context())
+
+	ctx := context.WithValue(context.Background(), contextKey("key"), "value")
+	reqWithCtx := req.WithContext(ctx)
+
+	require.Equal(t, ctx, reqWithCtx.Context())
+	require.Equal(t, context.Background(), req.Context(), "the original container should not be modified")
+
+	require.Equal(t, "value-value", reqWithCtx.Get("request"), "the context should be given to the dependencies")
+	require.Equal(t, "value", reqWithCtx.Get("app"), "the context should be given to the parent scopes")
+	require.Equal(t, "value", reqWithCtx.UnscopedGet("unscoped"))
+	require.Equal(t, ctx, reqWithCtx.ForkView().Context())
+
+	// The objects are shared with the original container.
+	require.Equal(t, "value-value", req.Get("request"))
+
+	subReq, _ := reqWithCtx.SubContainer()
+	require.Equal(t, context.Background(), subReq.Context())
+}
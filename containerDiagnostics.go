@@ -0,0 +1,450 @@
+package di
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CheckScopeLeaks looks for objects depending on objects of a more specific scope.
+// It uses the objects retrieved by the Build functions of this Container and its sub-containers.
+// Each leak is described by a message in the returned slice.
+// If no leak is found, the slice is empty.
+//
+// This should not happen when the objects are retrieved normally,
+// but it can happen if a Build function uses UnscopedGet to retrieve an object of a more specific scope.
+// The objects retrieved from a Container stored outside of the Build function (e.g. in a global variable)
+// can not be tracked and will not be reported.
+//
+// The leaks are recorded in the Container storing the retrieved object,
+// so they are no longer reported once this Container is deleted.
+func (ctn Container) CheckScopeLeaks() []string {
+	return checkScopeLeaks(ctn.core)
+}
+
+func checkScopeLeaks(core *containerCore) []string {
+	leaks := []string{}
+
+	core.m.RLock()
+
+	children := make([]*containerCore, 0, len(core.children)+1)
+	for child := range core.children {
+		children = append(children, child)
+	}
+	if core.unscopedChild != nil {
+		children = append(children, core.unscopedChild)
+	}
+
+	for _, leak := range core.scopeLeaks {
+		from, to := core.definitions[leak[0]], core.definitions[leak[1]]
+		leaks = append(leaks, fmt.Sprintf(
+			"`%s` in scope `%s` depends on `%s` in scope `%s`", from.Name, from.Scope, to.Name, to.Scope,
+		))
+	}
+
+	core.m.RUnlock()
+
+	for _, child := range children {
+		leaks = append(leaks, checkScopeLeaks(child)...)
+	}
+
+	return leaks
+}
+
+// recordScopeLeak records that the object at the given index is retrieved during the build of the requester,
+// if the requester is in a more generic scope than the object. It is used by CheckScopeLeaks.
+func (core *containerCore) recordScopeLeak(requester string, index int) {
+	from, ok := core.indexesByName[requester]
+	if !ok || core.definitionScopeLevels[from] >= core.definitionScopeLevels[index] {
+		return
+	}
+
+	leak := [2]int{from, index}
+
+	core.m.Lock()
+	defer core.m.Unlock()
+
+	if _, ok := core.scopeLeaksFound[leak]; ok {
+		return
+	}
+	if core.scopeLeaksFound == nil {
+		core.scopeLeaksFound = map[[2]int]struct{}{}
+	}
+	core.scopeLeaksFound[leak] = struct{}{}
+	core.scopeLeaks = append(core.scopeLeaks, leak)
+}
+
+// vertexDefinitionIndex returns the index of the definition of an object in the dependencies graph.
+// It returns -1 if the object was not built from a definition.
+// The core lock must be held by the caller.
+func (core *containerCore) vertexDefinitionIndex(vertex int) int {
+	if vertex >= 0 {
+		return vertex
+	}
+	return core.unshared[-vertex-1].index
+}
+
+// InProgressBuilds returns the chains of the objects that are currently being built
+// by the Container, its parents and its sub-containers.
+// Each chain contains the names of the definitions being built, from the first requested object
+// to the dependency that is currently being built. A chain usually matches a goroutine calling Get.
+// The chains are sorted, and a chain that is the beginning of a longer chain is omitted.
+//
+// It is a diagnostic tool that can help finding the build function that is stuck when a Get call hangs.
+// It is only a snapshot and the builds may have progressed when it returns.
+// The goroutines waiting for an object built by another goroutine are not included.
+// The builds are only tracked if the Container was created with the WithBuildTracking option.
+// Otherwise the returned slice is always empty.
+func (ctn Container) InProgressBuilds() [][]string {
+	stacks := [][]string{}
+
+	ctn.core.config.inProgress.Range(func(key, value interface{}) bool {
+		progress := key.(*buildProgress)
+		if !ctn.core.isRelatedTo(progress.core) {
+			return true
+		}
+		if stack := progress.get(); len(stack) > 0 {
+			stacks = append(stacks, stack)
+		}
+		return true
+	})
+
+	chains := [][]string{}
+
+	for _, stack := range stacks {
+		if !isStackPrefix(stack, stacks) {
+			chains = append(chains, stack)
+		}
+	}
+
+	sort.Slice(chains, func(i, j int) bool {
+		return strings.Join(chains[i], "\x00") < strings.Join(chains[j], "\x00")
+	})
+
+	return chains
+}
+
+// buildProgress contains the build stack of the object that is currently being built in a chain of builds.
+// The stack is not copied when it changes, only the slice is replaced.
+// It works because the names of a stack are never modified while the stack is in use.
+type buildProgress struct {
+	m     sync.Mutex
+	core  *containerCore
+	stack []string
+}
+
+// set replaces the build stack and returns the previous one.
+func (p *buildProgress) set(stack []string) []string {
+	p.m.Lock()
+	defer p.m.Unlock()
+	previous := p.stack
+	p.stack = stack
+	return previous
+}
+
+// get returns a copy of the build stack.
+func (p *buildProgress) get() []string {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return append([]string{}, p.stack...)
+}
+
+// isRelatedTo returns true if the other core is this core, one of its parents or one of its descendants.
+func (core *containerCore) isRelatedTo(other *containerCore) bool {
+	for c := core; c != nil; c = c.parent {
+		if c == other {
+			return true
+		}
+	}
+	for c := other; c != nil; c = c.parent {
+		if c == core {
+			return true
+		}
+	}
+	return false
+}
+
+// isStackPrefix returns true if stack is the beginning of a longer stack in stacks.
+func isStackPrefix(stack []string, stacks [][]string) bool {
+	for _, s := range stacks {
+		if len(s) <= len(stack) {
+			continue
+		}
+		isPrefix := true
+		for i := range stack {
+			if stack[i] != s[i] {
+				isPrefix = false
+				break
+			}
+		}
+		if isPrefix {
+			return true
+		}
+	}
+	return false
+}
+
+// Snapshot describes the shared objects that have been built by a Container at a given time.
+// It can be used in tests to check which objects were built.
+type Snapshot struct {
+	// Objects contains the built objects, in the order their definitions were inserted in the builder.
+	Objects []SnapshotObject
+}
+
+// SnapshotObject describes an object in a Snapshot.
+type SnapshotObject struct {
+	Name  string
+	Scope string
+}
+
+// IsBuilt returns true if the object with the given name is in the Snapshot.
+func (s Snapshot) IsBuilt(name string) bool {
+	for _, obj := range s.Objects {
+		if obj.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// BuiltNames returns the names of the objects in the Snapshot,
+// in the order their definitions were inserted in the builder.
+func (s Snapshot) BuiltNames() []string {
+	names := make([]string, len(s.Objects))
+
+	for i, obj := range s.Objects {
+		names[i] = obj.Name
+	}
+
+	return names
+}
+
+// Snapshot returns the list of the shared objects that are currently built in this Container.
+// The objects of the parent containers and the sub-containers are not included.
+// Unshared objects are never included.
+// Snapshot does not build any object.
+func (ctn Container) Snapshot() Snapshot {
+	built := make([]bool, len(ctn.core.definitions))
+	markBuilt(ctn.core, built, false)
+	return newSnapshot(ctn.core, built)
+}
+
+// SnapshotWithSubContainers works like Snapshot,
+// but it also includes the objects built in the sub-containers of this Container.
+// If an object is built in several sub-containers, it only appears once in the Snapshot.
+func (ctn Container) SnapshotWithSubContainers() Snapshot {
+	built := make([]bool, len(ctn.core.definitions))
+	markBuilt(ctn.core, built, true)
+	return newSnapshot(ctn.core, built)
+}
+
+// BuiltNames returns the names of the shared objects that are currently built in this Container,
+// in the order their definitions were inserted in the builder.
+// It is a shortcut for ctn.Snapshot().BuiltNames().
+func (ctn Container) BuiltNames() []string {
+	return ctn.Snapshot().BuiltNames()
+}
+
+// UnsharedCount returns the number of unshared objects currently kept by this Container.
+// Only the objects whose definition has a Close function are kept, as explained in UnsharedInstances.
+// The objects created with GetOrStore and the objects of the parent containers and sub-containers are not counted.
+func (ctn Container) UnsharedCount() int {
+	ctn.core.m.RLock()
+	defer ctn.core.m.RUnlock()
+
+	count := 0
+
+	for _, u := range ctn.core.unshared {
+		if u.index >= 0 {
+			count++
+		}
+	}
+
+	return count
+}
+
+// markBuilt sets built[index] to true for each object built in the core,
+// and in its sub-containers if recursive is true.
+func markBuilt(core *containerCore, built []bool, recursive bool) {
+	core.m.RLock()
+
+	for index := range core.isBuilt {
+		if atomic.LoadInt32(&core.isBuilt[index]) == 1 {
+			built[index] = true
+		}
+	}
+
+	children := make([]*containerCore, 0, len(core.children)+1)
+	for child := range core.children {
+		children = append(children, child)
+	}
+	if core.unscopedChild != nil {
+		children = append(children, core.unscopedChild)
+	}
+
+	core.m.RUnlock()
+
+	if !recursive {
+		return
+	}
+
+	for _, child := range children {
+		markBuilt(child, built, true)
+	}
+}
+
+func newSnapshot(core *containerCore, built []bool) Snapshot {
+	snapshot := Snapshot{Objects: []SnapshotObject{}}
+
+	for index, isBuilt := range built {
+		if isBuilt {
+			snapshot.Objects = append(snapshot.Objects, SnapshotObject{
+				Name:  core.definitions[index].Name,
+				Scope: core.definitions[index].Scope,
+			})
+		}
+	}
+
+	return snapshot
+}
+
+// WarmResult describes the outcome of the construction of an object by WarmAll.
+type WarmResult struct {
+	// Name is the name of the definition.
+	Name string
+	// Built is true if the object is available in the Container.
+	Built bool
+	// Skipped is true if WarmAll did not try to build the object.
+	// In this case, Err explains why.
+	Skipped bool
+	// Err is the error returned when the object was built, or the reason why it was skipped.
+	Err error
+	// Duration is the time spent retrieving the object.
+	Duration time.Duration
+}
+
+// WarmAll tries to build all the shared objects that can be retrieved from this Container,
+// including the ones stored in the parent containers.
+// It does not stop at the first error, and it returns one WarmResult for each definition,
+// in the order the definitions were inserted in the builder.
+//
+// The definitions in a more specific scope than the Container scope are skipped,
+// and so are the unshared definitions, as their objects would not be kept by the Container.
+// If a build fails, the dependencies built in the process are closed right away
+// and removed from the containers storing them, so that a failed warm-up does not leave partially built objects.
+// The errors of their Close functions are given to the logger.
+// The dependencies built by the other goroutines in the meantime are not affected,
+// but a dependency built by the failed warm-up may have been retrieved by another goroutine before it is closed,
+// so WarmAll should be called before the Container is used concurrently.
+func (ctn Container) WarmAll() []WarmResult {
+	results := make([]WarmResult, 0, len(ctn.core.definitions))
+
+	for index, def := range ctn.core.definitions {
+		result := WarmResult{Name: def.Name}
+
+		switch {
+		case ctn.core.definitionScopeLevels[index] > ctn.core.scopeLevel:
+			result.Skipped = true
+			result.Err = fmt.Errorf("`%s` is in the `%s` scope which is more specific than the container scope", def.Name, def.Scope)
+		case def.Unshared:
+			result.Skipped = true
+			result.Err = fmt.Errorf("`%s` is unshared", def.Name)
+		default:
+			warm := ctn
+			warm.warmUp = &warmUpRecorder{}
+			start := time.Now()
+			_, result.Err = warm.SafeGet(index)
+			result.Duration = time.Since(start)
+			result.Built = result.Err == nil
+			if result.Err != nil {
+				warm.warmUp.closeAll()
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// warmUpRecorder records the objects built while WarmAll retrieves an object.
+type warmUpRecorder struct {
+	m       sync.Mutex
+	objects []warmUpObject
+}
+
+// warmUpObject identifies an object built during a warm-up.
+// The vertex is the index of the definition for a shared object,
+// and the vertex of the dependency graph (a negative number) for an unshared object.
+type warmUpObject struct {
+	core   *containerCore
+	vertex int
+}
+
+// record adds an object to the recorder.
+func (r *warmUpRecorder) record(core *containerCore, vertex int) {
+	r.m.Lock()
+	r.objects = append(r.objects, warmUpObject{core: core, vertex: vertex})
+	r.m.Unlock()
+}
+
+// closeAll removes the recorded objects from their containers and closes them.
+// The objects are closed in the reverse order of their construction,
+// so that an object is closed before its dependencies.
+// The objects of the containers that have already been deleted are skipped.
+func (r *warmUpRecorder) closeAll() {
+	r.m.Lock()
+	objects := r.objects
+	r.objects = nil
+	r.m.Unlock()
+
+	for i := len(objects) - 1; i >= 0; i-- {
+		core := objects[i].core
+		obj, closeFunc, name := core.removeWarmUpObject(objects[i].vertex)
+		if closeFunc == nil {
+			continue
+		}
+		if err := closeObject(obj, closeFunc, name); err != nil {
+			core.config.logError(fmt.Errorf("could not close `%s` after a failed warm-up: %w", name, err))
+		}
+	}
+}
+
+// removeWarmUpObject removes an object from the core, so that it is not closed again when the core is deleted.
+// It returns the object, its close function and its name.
+// The close function is nil if the object does not need to be closed,
+// or if it is not stored in the core anymore.
+func (core *containerCore) removeWarmUpObject(vertex int) (interface{}, func(obj interface{}) error, string) {
+	core.m.Lock()
+	defer core.m.Unlock()
+
+	if core.closed {
+		return nil, nil, ""
+	}
+
+	if vertex < 0 {
+		unshared := core.unshared[-vertex-1]
+		core.unshared[-vertex-1] = unsharedObject{index: -1, name: unshared.name}
+		core.dependencies.RemoveVertex(vertex)
+		return unshared.obj, unshared.close, unshared.name
+	}
+
+	if atomic.LoadInt32(&core.isBuilt[vertex]) == 0 {
+		return nil, nil, ""
+	}
+
+	obj := core.object(vertex)
+	closeFunc := core.objectCloseFunc(context.Background(), vertex)
+
+	core.dependencies.RemoveVertex(vertex)
+	core.setObject(vertex, nil)
+	core.cleanups[vertex] = nil
+	core.building[vertex] = nil
+	atomic.StoreInt32(&core.isBuilt[vertex], 0)
+
+	return obj, closeFunc, core.definitions[vertex].Name
+}
@@ -0,0 +1,335 @@
+package di
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckScopeLeaks(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name: "app-object",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockB{}, nil
+		},
+	})
+	b.Add(&Def{
+		Name:  "request-object",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{BField: ctn.Get("app-object").(*mockB)}, nil
+		},
+	})
+	b.Add(&Def{
+		Name: "leaking-object",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.UnscopedGet("request-object"), nil
+		},
+	})
+
+	app, _ := b.Build()
+	request, _ := app.SubContainer()
+	request.Get("request-object")
+
+	require.Empty(t, app.CheckScopeLeaks())
+	require.Empty(t, request.CheckScopeLeaks())
+
+	// The request object is already built by the unscoped sub-container when it is retrieved the second time.
+	app.UnscopedGet("request-object")
+	app.Get("leaking-object")
+
+	require.Equal(t, []string{
+		"`leaking-object` in scope `app` depends on `request-object` in scope `request`",
+	}, app.CheckScopeLeaks())
+	require.Empty(t, request.CheckScopeLeaks())
+
+	require.Nil(t, app.Clean())
+	require.Empty(t, app.CheckScopeLeaks(), "the leak is forgotten with the unscoped sub-container")
+}
+
+func TestSnapshot(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	for _, name := range []string{"app-1", "app-2"} {
+		b.Add(&Def{
+			Name: name,
+			Build: func(ctn Container) (interface{}, error) {
+				return nil, nil
+			},
+		})
+	}
+	for _, name := range []string{"request-1", "request-2", "request-3"} {
+		b.Add(&Def{
+			Name:  name,
+			Scope: Request,
+			Build: func(ctn Container) (interface{}, error) {
+				return nil, nil
+			},
+		})
+	}
+	b.Add(&Def{
+		Name:     "unshared",
+		Unshared: true,
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, nil
+		},
+	})
+
+	app, _ := b.Build()
+	req1, _ := app.SubContainer()
+	req2, _ := app.SubContainer()
+
+	require.Empty(t, app.Snapshot().BuiltNames())
+
+	req1.Get("request-1")
+	req1.Get("app-2")
+	req2.Get("request-3")
+	req2.Get("request-1")
+	app.Get("unshared")
+
+	snapshot := req1.Snapshot()
+	require.Equal(t, []string{"request-1"}, snapshot.BuiltNames())
+	require.True(t, snapshot.IsBuilt("request-1"))
+	require.False(t, snapshot.IsBuilt("request-2"))
+	require.False(t, snapshot.IsBuilt("app-2"))
+	require.Equal(t, []SnapshotObject{{Name: "request-1", Scope: Request}}, snapshot.Objects)
+
+	require.Equal(t, []string{"app-2"}, app.Snapshot().BuiltNames())
+	require.Equal(t, []string{"app-2", "request-1", "request-3"}, app.SnapshotWithSubContainers().BuiltNames())
+}
+
+func TestBuiltNamesAndUnsharedCount(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	buildFunc := func(ctn Container) (interface{}, error) { return &mockA{}, nil }
+	closeFunc := func(obj interface{}) error { return nil }
+
+	b.Add(&Def{Name: "app-a", Build: buildFunc})
+	b.Add(&Def{Name: "app-b", Build: buildFunc})
+	b.Add(&Def{Name: "request", Scope: Request, Build: buildFunc})
+	b.Add(&Def{Name: "unshared", Scope: Request, Build: buildFunc, Close: closeFunc, Unshared: true})
+	b.Add(&Def{Name: "unshared-no-close", Scope: Request, Build: buildFunc, Unshared: true})
+
+	app, _ := b.Build()
+	req, _ := app.SubContainer()
+
+	require.Equal(t, []string{}, req.BuiltNames())
+	require.Equal(t, 0, req.UnsharedCount())
+
+	req.Get("app-b")
+	req.Get("request")
+	req.Get("unshared")
+	req.Get("unshared")
+	req.Get("unshared-no-close")
+	req.GetOrStore("stored", func() (interface{}, error) { return "stored", nil }, nil)
+
+	require.Equal(t, []string{"app-b"}, app.BuiltNames())
+	require.Equal(t, []string{"request"}, req.BuiltNames())
+	require.Equal(t, 2, req.UnsharedCount())
+	require.Equal(t, 0, app.UnsharedCount())
+}
+
+func TestWarmAll(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name: "app",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+	})
+	b.Add(&Def{
+		Name: "app-error",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, errors.New("build error")
+		},
+	})
+	b.Add(&Def{
+		Name:  "request",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+	})
+	b.Add(&Def{
+		Name:     "unshared",
+		Unshared: true,
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+	})
+
+	app, _ := b.Build()
+
+	results := app.WarmAll()
+	require.Len(t, results, 4)
+
+	require.Equal(t, "app", results[0].Name)
+	require.True(t, results[0].Built)
+	require.Nil(t, results[0].Err)
+
+	require.Equal(t, "app-error", results[1].Name)
+	require.False(t, results[1].Built)
+	require.False(t, results[1].Skipped)
+	require.NotNil(t, results[1].Err)
+
+	require.Equal(t, "request", results[2].Name)
+	require.False(t, results[2].Built)
+	require.True(t, results[2].Skipped)
+
+	require.Equal(t, "unshared", results[3].Name)
+	require.True(t, results[3].Skipped)
+
+	require.Equal(t, []string{"app"}, app.Snapshot().BuiltNames())
+
+	request, _ := app.SubContainer()
+	results = request.WarmAll()
+	require.True(t, results[0].Built)
+	require.True(t, results[2].Built)
+	require.Equal(t, []string{"request"}, request.Snapshot().BuiltNames())
+}
+
+func TestWarmAllClosesFailedDependencies(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	closed := []string{}
+	closeFunc := func(name string) func(obj interface{}) error {
+		return func(obj interface{}) error {
+			closed = append(closed, name)
+			return nil
+		}
+	}
+
+	b.Add(&Def{
+		Name:  "config",
+		Build: func(ctn Container) (interface{}, error) { return &mockA{}, nil },
+		Close: closeFunc("config"),
+	})
+	b.Add(&Def{
+		Name:  "request",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			ctn.Get("db")
+			ctn.Get("conn")
+			return nil, errors.New("build error")
+		},
+		Close: closeFunc("request"),
+	})
+	b.Add(&Def{
+		Name: "db",
+		Build: func(ctn Container) (interface{}, error) {
+			ctn.Get("config")
+			return &mockA{}, nil
+		},
+		Close: closeFunc("db"),
+	})
+	b.Add(&Def{
+		Name:     "conn",
+		Unshared: true,
+		Build:    func(ctn Container) (interface{}, error) { return &mockA{}, nil },
+		Close:    closeFunc("conn"),
+	})
+
+	app, _ := b.Build()
+	request, _ := app.SubContainer()
+
+	app.Get("config")
+
+	results := request.WarmAll()
+	require.True(t, results[0].Built)
+	require.False(t, results[1].Built)
+	require.NotNil(t, results[1].Err)
+	require.True(t, results[2].Built, "db is built again by its own warm-up")
+
+	require.Equal(t, []string{"conn", "db"}, closed, "config was built before the warm-up and should be kept")
+	require.Equal(t, []string{"config", "db"}, app.Snapshot().BuiltNames())
+	require.Equal(t, 0, request.UnsharedCount())
+
+	require.Nil(t, app.DeleteWithSubContainers())
+	require.Equal(t, []string{"conn", "db", "db", "config"}, closed, "the closed objects should not be closed again")
+}
+
+func TestInProgressBuilds(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	b.Add(&Def{
+		Name: "a",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.SafeGet("b")
+		},
+	})
+	b.Add(&Def{
+		Name: "b",
+		Build: func(ctn Container) (interface{}, error) {
+			close(started)
+			<-release
+			return "b", nil
+		},
+	})
+	b.Add(&Def{
+		Name:  "c",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.SafeGet("a")
+		},
+	})
+
+	app, err := b.Build(WithBuildTracking())
+	require.Nil(t, err)
+	req, _ := app.SubContainer()
+	otherReq, _ := app.SubContainer()
+
+	require.Equal(t, [][]string{}, app.InProgressBuilds())
+
+	done := make(chan error)
+	go func() {
+		_, err := req.SafeGet("c")
+		done <- err
+	}()
+
+	<-started
+	require.Equal(t, [][]string{{"c", "a", "b"}}, app.InProgressBuilds())
+	require.Equal(t, [][]string{{"c", "a", "b"}}, req.InProgressBuilds())
+	require.Equal(t, [][]string{}, otherReq.InProgressBuilds(), "the builds of a sibling container should not be included")
+
+	close(release)
+	require.Nil(t, <-done)
+	require.Equal(t, [][]string{}, app.InProgressBuilds())
+}
+
+func TestInProgressBuildsWithoutTracking(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	b.Add(&Def{
+		Name: "a",
+		Build: func(ctn Container) (interface{}, error) {
+			close(started)
+			<-release
+			return "a", nil
+		},
+	})
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	done := make(chan error)
+	go func() {
+		_, err := app.SafeGet("a")
+		done <- err
+	}()
+
+	<-started
+	require.Equal(t, [][]string{}, app.InProgressBuilds(), "the builds are not tracked without the option")
+
+	close(release)
+	require.Nil(t, <-done)
+}
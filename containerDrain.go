@@ -0,0 +1,99 @@
+package di
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// Drain stops the Container from building new objects, without deleting it.
+// After Drain is called, Get, SafeGet, Fill and GetOrStore still return the objects that are already built,
+// but they return an error instead of building an object that does not exist yet.
+// The unshared objects can not be built anymore either.
+// It also applies to the sub-containers, including the ones created after Drain is called.
+//
+// It is meant to be used as a shutdown step: the work that is already started can be finished
+// with the existing objects, but no new work can start. WaitForBuilds can be used to wait
+// for the objects that were being built when Drain was called, before calling Delete.
+func (ctn Container) Drain() {
+	ctn.core.m.Lock()
+	atomic.StoreInt32(&ctn.core.draining, 1)
+	ctn.core.m.Unlock()
+}
+
+// IsDraining returns true if Drain has been called on the Container or one of its parents.
+func (ctn Container) IsDraining() bool {
+	return ctn.core.isDraining()
+}
+
+// WaitForBuilds waits until the shared objects that are being built by the Container
+// and its sub-containers are built. It returns an error if the context is done first.
+// The objects whose construction starts while WaitForBuilds is waiting are also waited for,
+// so it should be called after Drain.
+// The unshared objects are not waited for. If one of them is built after the deletion of the Container,
+// it is closed right away and an error is returned to its caller.
+func (ctn Container) WaitForBuilds(ctx context.Context) error {
+	return waitForBuilds(ctx, ctn.core)
+}
+
+func waitForBuilds(ctx context.Context, core *containerCore) error {
+	for {
+		core.m.RLock()
+		buildsDone := core.buildsDone
+		children := make([]*containerCore, 0, len(core.children)+1)
+		for child := range core.children {
+			children = append(children, child)
+		}
+		if core.unscopedChild != nil {
+			children = append(children, core.unscopedChild)
+		}
+		core.m.RUnlock()
+
+		if buildsDone == nil {
+			for _, child := range children {
+				if err := waitForBuilds(ctx, child); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		select {
+		case <-buildsDone:
+		case <-ctx.Done():
+			return fmt.Errorf("could not wait for the builds%s to finish: %w", core.nameSuffix(), ctx.Err())
+		}
+	}
+}
+
+// isDraining returns true if Drain has been called on the core or one of its parents.
+func (core *containerCore) isDraining() bool {
+	for c := core; c != nil; c = c.parent {
+		if atomic.LoadInt32(&c.draining) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// startBuild registers an object that is being built. The lock must be held by the caller.
+func (core *containerCore) startBuild() {
+	if core.numBuilds == 0 {
+		core.buildsDone = make(chan struct{})
+	}
+	core.numBuilds++
+}
+
+// finishBuild unregisters an object that was being built. The lock must be held by the caller.
+func (core *containerCore) finishBuild() {
+	core.numBuilds--
+	if core.numBuilds == 0 {
+		close(core.buildsDone)
+		core.buildsDone = nil
+	}
+}
+
+// formatDrainingError formats the error returned when an object is requested from a draining Container.
+func formatDrainingError(core *containerCore, def Def) error {
+	return fmt.Errorf("could not get `%s`%s because the container is draining", def.Name, core.nameSuffix())
+}
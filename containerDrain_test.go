@@ -0,0 +1,127 @@
+package di
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrain(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name: "built",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+	})
+	b.Add(&Def{
+		Name: "not-built",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+	})
+	b.Add(&Def{
+		Name:     "unshared",
+		Unshared: true,
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+	})
+	b.Add(&Def{
+		Name:  "request",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+	})
+
+	app, _ := b.Build()
+	req, _ := app.SubContainer()
+
+	built := app.Get("built")
+	stored, _ := req.GetOrStore("stored", func() (interface{}, error) { return &mockA{}, nil }, nil)
+	require.False(t, app.IsDraining())
+
+	app.Drain()
+	require.True(t, app.IsDraining())
+	require.True(t, req.IsDraining())
+	require.False(t, app.IsClosed())
+
+	obj, err := app.SafeGet("built")
+	require.Nil(t, err)
+	require.True(t, obj == built)
+
+	obj, err = req.SafeGet("built")
+	require.Nil(t, err)
+	require.True(t, obj == built)
+
+	_, err = app.SafeGet("not-built")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "draining")
+
+	_, err = app.SafeGet("unshared")
+	require.NotNil(t, err)
+
+	_, err = req.SafeGet("request")
+	require.NotNil(t, err)
+
+	newReq, _ := app.SubContainer()
+	_, err = newReq.SafeGet("request")
+	require.NotNil(t, err)
+
+	_, err = app.GetOrStore("stored", func() (interface{}, error) { return &mockA{}, nil }, nil)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "draining")
+
+	obj, err = req.GetOrStore("stored", func() (interface{}, error) { return nil, nil }, nil)
+	require.Nil(t, err, "the stored objects are still returned")
+	require.True(t, obj == stored)
+
+	require.Nil(t, app.WaitForBuilds(context.Background()))
+	require.Nil(t, app.Delete())
+}
+
+func TestWaitForBuilds(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	b.Add(&Def{
+		Name:  "slow",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			close(started)
+			<-release
+			return &mockA{}, nil
+		},
+	})
+
+	app, _ := b.Build()
+	req, _ := app.SubContainer()
+
+	done := make(chan error)
+	go func() {
+		_, err := req.SafeGet("slow")
+		done <- err
+	}()
+
+	<-started
+	app.Drain()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.NotNil(t, app.WaitForBuilds(ctx), "the build is not finished before the timeout")
+
+	waited := make(chan error)
+	go func() {
+		waited <- app.WaitForBuilds(context.Background())
+	}()
+
+	close(release)
+	require.Nil(t, <-done, "the object that was building when Drain was called is still returned")
+	require.Nil(t, <-waited)
+}
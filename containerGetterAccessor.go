@@ -0,0 +1,46 @@
+package di
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// TypeAccessor returns a function that retrieves the object matching the given type,
+// like ctn.SafeGet(typ) would. The definition and the Container holding the object
+// are resolved once when TypeAccessor is called, so that the returned function
+// only needs to check if the object is already built to return it.
+// It is meant to be used in hot paths.
+//
+// In case there are more than one definition matching the given type,
+// the chosen one depends on the TypeResolution of the Container, as with SafeGet.
+// TypeAccessor returns an error if the type is not defined,
+// or if the definition scope is not reachable from this Container.
+func (ctn Container) TypeAccessor(typ reflect.Type) (func() (interface{}, error), error) {
+	indexes := ctn.core.indexesByType[typ]
+	if len(indexes) == 0 {
+		return nil, newSentinelError(ErrNotDefined, "could not get type `%s`%s because it is not defined", typ, ctn.core.nameSuffix())
+	}
+
+	index, err := ctn.core.selectTypeIndex(typ, indexes)
+	if err != nil {
+		return nil, err
+	}
+
+	core := ctn.core.storageCore(index)
+	if core == nil {
+		return nil, newSentinelError(
+			ErrScopeMismatch,
+			"could not get `%s`%s because it requires `%s` scope which does not match this container scope or any of its parents scope",
+			ctn.core.definitions[index].Name,
+			ctn.core.nameSuffix(),
+			ctn.core.definitions[index].Scope,
+		)
+	}
+
+	return func() (interface{}, error) {
+		if atomic.LoadInt32(&core.isBuilt[index]) == 1 {
+			return core.object(index), nil
+		}
+		return ctn.SafeGet(index)
+	}, nil
+}
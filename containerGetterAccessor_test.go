@@ -0,0 +1,76 @@
+package di
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypeAccessor(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name: "a1",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{SField: "a1"}, nil
+		},
+		Is: NewIs(&mockA{}),
+	})
+	b.Add(&Def{
+		Name: "a2",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{SField: "a2"}, nil
+		},
+		Is: NewIs(&mockA{}),
+	})
+	b.Add(&Def{
+		Name:  "b",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockB{}, nil
+		},
+		Is: NewIs(&mockB{}),
+	})
+
+	app, _ := b.Build()
+	request, _ := app.SubContainer()
+
+	accessor, err := request.TypeAccessor(reflect.TypeOf(&mockA{}))
+	require.Nil(t, err)
+
+	obj1, err := accessor()
+	require.Nil(t, err)
+	require.Equal(t, "a2", obj1.(*mockA).SField)
+
+	obj2, err := accessor()
+	require.Nil(t, err)
+	require.True(t, obj1 == obj2)
+	require.True(t, obj1 == app.Get("a2"))
+
+	_, err = app.TypeAccessor(reflect.TypeOf(&mockB{}))
+	require.NotNil(t, err)
+
+	_, err = app.TypeAccessor(reflect.TypeOf(&mockC{}))
+	require.NotNil(t, err)
+}
+
+func BenchmarkTypeAccessor(b *testing.B) {
+	builder, _ := NewEnhancedBuilder()
+
+	builder.Add(&Def{
+		Name: "a",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+		Is: NewIs(&mockA{}),
+	})
+
+	app, _ := builder.Build()
+	request, _ := app.SubContainer()
+	accessor, _ := request.TypeAccessor(reflect.TypeOf(&mockA{}))
+
+	for i := 0; i < b.N; i++ {
+		accessor()
+	}
+}
@@ -0,0 +1,110 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// GetAllForType retrieves all the objects whose definition includes the given type in its Is field.
+// The objects are returned in the order their definitions were inserted in the builder.
+// If no definition includes the type, the returned slice is empty.
+//
+// Each object is retrieved with SafeGet, so each of them must be reachable from the Container scope.
+// If an object can not be retrieved, the returned slice contains the objects that were retrieved before,
+// and the error is returned.
+func (ctn Container) GetAllForType(typ reflect.Type) ([]interface{}, error) {
+	indexes := ctn.core.indexesByType[typ]
+	objects := make([]interface{}, 0, len(indexes))
+
+	for _, index := range indexes {
+		obj, err := ctn.SafeGet(index)
+		if err != nil {
+			return objects, fmt.Errorf("could not get all the objects for type `%s`: %w", typ, err)
+		}
+
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+// GetAllForTypeWithPrefix retrieves all the objects whose definition includes the given type in its Is field
+// and whose name starts with the given prefix. The objects are returned in a map, with the definition names as keys.
+// The objects are built in the order their definitions were inserted in the builder.
+//
+// Each object is retrieved with SafeGet, so each of them must be reachable from the Container scope.
+// If an object can not be retrieved, the returned map contains the objects that were retrieved before,
+// and the error is returned.
+func (ctn Container) GetAllForTypeWithPrefix(typ reflect.Type, prefix string) (map[string]interface{}, error) {
+	objects := map[string]interface{}{}
+
+	for _, index := range ctn.core.indexesByType[typ] {
+		def := ctn.core.definitions[index]
+
+		if !strings.HasPrefix(def.Name, prefix) {
+			continue
+		}
+
+		obj, err := ctn.SafeGet(index)
+		if err != nil {
+			return objects, fmt.Errorf("could not get all the objects for type `%s` and prefix `%s`: %w", typ, prefix, err)
+		}
+
+		objects[def.Name] = obj
+	}
+
+	return objects, nil
+}
+
+// GetAllForTypes retrieves all the objects whose definition includes all the given types in its Is field.
+// The objects are returned in a map, with the definition names as keys.
+// The objects are built in the order their definitions were inserted in the builder.
+// At least one type is required.
+//
+// Each object is retrieved with SafeGet, so each of them must be reachable from the Container scope.
+// If an object can not be retrieved, the returned map contains the objects that were retrieved before,
+// and the error is returned.
+func (ctn Container) GetAllForTypes(types ...reflect.Type) (map[string]interface{}, error) {
+	objects := map[string]interface{}{}
+
+	if len(types) == 0 {
+		return objects, fmt.Errorf("could not get all the objects for types because no type was given")
+	}
+
+	for _, index := range ctn.core.indexesForAllTypes(types) {
+		def := ctn.core.definitions[index]
+
+		obj, err := ctn.SafeGet(index)
+		if err != nil {
+			return objects, fmt.Errorf("could not get all the objects for types %v: %w", types, err)
+		}
+
+		objects[def.Name] = obj
+	}
+
+	return objects, nil
+}
+
+// indexesForAllTypes returns the indexes of the definitions including all the given types in their Is field,
+// in insertion order.
+func (core *containerCore) indexesForAllTypes(types []reflect.Type) []int {
+	indexes := []int{}
+
+	for _, index := range core.indexesByType[types[0]] {
+		inAll := true
+
+		for _, typ := range types[1:] {
+			if !containsInt(core.indexesByType[typ], index) {
+				inAll = false
+				break
+			}
+		}
+
+		if inAll {
+			indexes = append(indexes, index)
+		}
+	}
+
+	return indexes
+}
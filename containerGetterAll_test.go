@@ -0,0 +1,185 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAllForType(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	handlerType := reflect.TypeOf((*mockHandler)(nil)).Elem()
+
+	for _, name := range []string{"handler.foo", "handler.bar"} {
+		name := name
+		b.Add(&Def{
+			Name: name,
+			Build: func(ctn Container) (interface{}, error) {
+				return &mockHandlerImpl{name: name}, nil
+			},
+			Is: []reflect.Type{handlerType},
+		})
+	}
+	b.Add(&Def{
+		Name: "not-a-handler",
+		Build: func(ctn Container) (interface{}, error) {
+			return "not a handler", nil
+		},
+	})
+	b.Add(&Def{
+		Name:  "request.handler",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockHandlerImpl{name: "request.handler"}, nil
+		},
+		Is: []reflect.Type{handlerType},
+	})
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	req, _ := app.SubContainer()
+
+	objects, err := req.GetAllForType(handlerType)
+	require.Nil(t, err)
+	require.Equal(t, []interface{}{
+		&mockHandlerImpl{name: "handler.foo"},
+		&mockHandlerImpl{name: "handler.bar"},
+		&mockHandlerImpl{name: "request.handler"},
+	}, objects)
+
+	// The request handler can not be retrieved from the app container.
+	objects, err = app.GetAllForType(handlerType)
+	require.NotNil(t, err)
+	require.True(t, errors.Is(err, ErrScopeMismatch))
+	require.Len(t, objects, 2)
+
+	objects, err = app.GetAllForType(reflect.TypeOf(0))
+	require.Nil(t, err)
+	require.Empty(t, objects)
+}
+
+func TestGetAllForTypeWithPrefix(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	handlerType := reflect.TypeOf((*mockHandler)(nil)).Elem()
+
+	for _, name := range []string{"handler.foo", "handler.bar", "other.baz"} {
+		name := name
+		b.Add(&Def{
+			Name: name,
+			Build: func(ctn Container) (interface{}, error) {
+				return &mockHandlerImpl{name: name}, nil
+			},
+			Is: []reflect.Type{handlerType},
+		})
+	}
+	b.Add(&Def{
+		Name: "handler.not-a-handler",
+		Build: func(ctn Container) (interface{}, error) {
+			return "not a handler", nil
+		},
+	})
+	b.Add(&Def{
+		Name:  "request.handler",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockHandlerImpl{name: "request.handler"}, nil
+		},
+		Is: []reflect.Type{handlerType},
+	})
+	b.Add(&Def{
+		Name: "error.handler",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, errors.New("build error")
+		},
+		Is: []reflect.Type{handlerType},
+	})
+
+	app, _ := b.Build()
+	request, _ := app.SubContainer()
+
+	objects, err := app.GetAllForTypeWithPrefix(handlerType, "handler.")
+	require.Nil(t, err)
+	require.Len(t, objects, 2)
+	require.Equal(t, "handler.foo", objects["handler.foo"].(mockHandler).Handle())
+	require.Equal(t, "handler.bar", objects["handler.bar"].(mockHandler).Handle())
+
+	objects, err = app.GetAllForTypeWithPrefix(handlerType, "unknown.")
+	require.Nil(t, err)
+	require.Empty(t, objects)
+
+	// scope
+	_, err = app.GetAllForTypeWithPrefix(handlerType, "request.")
+	require.NotNil(t, err)
+	objects, err = request.GetAllForTypeWithPrefix(handlerType, "request.")
+	require.Nil(t, err)
+	require.Len(t, objects, 1)
+
+	// build error
+	_, err = app.GetAllForTypeWithPrefix(handlerType, "error.")
+	require.NotNil(t, err)
+}
+
+func TestGetAllForTypes(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	handlerType := reflect.TypeOf((*mockHandler)(nil)).Elem()
+	stringType := reflect.TypeOf("")
+
+	b.Add(&Def{
+		Name: "handler",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockHandlerImpl{name: "handler"}, nil
+		},
+		Is: []reflect.Type{handlerType},
+	})
+	b.Add(&Def{
+		Name: "both",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockHandlerImpl{name: "both"}, nil
+		},
+		Is: []reflect.Type{stringType, handlerType},
+	})
+	b.Add(&Def{
+		Name: "string",
+		Build: func(ctn Container) (interface{}, error) {
+			return "string", nil
+		},
+		Is: []reflect.Type{stringType},
+	})
+	b.Add(&Def{
+		Name:  "request.both",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockHandlerImpl{name: "request.both"}, nil
+		},
+		Is: []reflect.Type{handlerType, stringType},
+	})
+
+	app, _ := b.Build()
+	request, _ := app.SubContainer()
+
+	objects, err := request.GetAllForTypes(handlerType, stringType)
+	require.Nil(t, err)
+	require.Len(t, objects, 2)
+	require.Equal(t, "both", objects["both"].(mockHandler).Handle())
+	require.Equal(t, "request.both", objects["request.both"].(mockHandler).Handle())
+
+	objects, err = request.GetAllForTypes(handlerType)
+	require.Nil(t, err)
+	require.Len(t, objects, 3)
+
+	objects, err = request.GetAllForTypes(handlerType, reflect.TypeOf(0))
+	require.Nil(t, err)
+	require.Empty(t, objects)
+
+	_, err = app.GetAllForTypes(handlerType, stringType)
+	require.NotNil(t, err, "request.both is not reachable from the app container")
+
+	_, err = app.GetAllForTypes()
+	require.NotNil(t, err)
+}
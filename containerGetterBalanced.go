@@ -0,0 +1,76 @@
+package di
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync/atomic"
+)
+
+// GetRoundRobin retrieves one of the objects whose definition includes the given type in its Is field.
+// Each call returns the next matching object, in the order their definitions were inserted in the builder,
+// and starts again with the first one after the last one.
+// The rotation is specific to this Container and is safe for concurrent use.
+//
+// Only the definitions that are reachable from the Container scope are candidates.
+// It returns an error if there is no candidate or if the selected object can not be built.
+func (ctn Container) GetRoundRobin(typ reflect.Type) (interface{}, error) {
+	candidates := ctn.reachableIndexesForType(typ)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("could not get type `%s`%s because there is no definition for this type in this scope", typ, ctn.core.nameSuffix())
+	}
+
+	n := atomic.AddUint64(ctn.core.roundRobinCounter(typ), 1) - 1
+
+	return ctn.SafeGet(candidates[n%uint64(len(candidates))])
+}
+
+// GetRandom retrieves one of the objects whose definition includes the given type in its Is field.
+// The object is chosen randomly among the candidates.
+//
+// Only the definitions that are reachable from the Container scope are candidates.
+// It returns an error if there is no candidate or if the selected object can not be built.
+func (ctn Container) GetRandom(typ reflect.Type) (interface{}, error) {
+	candidates := ctn.reachableIndexesForType(typ)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("could not get type `%s`%s because there is no definition for this type in this scope", typ, ctn.core.nameSuffix())
+	}
+
+	return ctn.SafeGet(candidates[rand.Intn(len(candidates))])
+}
+
+// reachableIndexesForType returns the indexes of the definitions matching the given type
+// that can be retrieved from the Container scope.
+func (ctn Container) reachableIndexesForType(typ reflect.Type) []int {
+	indexes := []int{}
+
+	for _, index := range ctn.core.indexesByType[typ] {
+		if ctn.core.definitionScopeLevels[index] <= ctn.core.scopeLevel {
+			indexes = append(indexes, index)
+		}
+	}
+
+	return indexes
+}
+
+// roundRobinCounter returns the counter used by GetRoundRobin for the given type.
+// The counter is created if it does not exist yet.
+func (core *containerCore) roundRobinCounter(typ reflect.Type) *uint64 {
+	core.m.RLock()
+	counter, ok := core.roundRobin[typ]
+	core.m.RUnlock()
+
+	if ok {
+		return counter
+	}
+
+	core.m.Lock()
+	defer core.m.Unlock()
+
+	if counter, ok = core.roundRobin[typ]; !ok {
+		counter = new(uint64)
+		core.roundRobin[typ] = counter
+	}
+
+	return counter
+}
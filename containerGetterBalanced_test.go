@@ -0,0 +1,99 @@
+package di
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newBalancedTestBuilder() *EnhancedBuilder {
+	b, _ := NewEnhancedBuilder()
+
+	for _, def := range []struct {
+		name  string
+		scope string
+	}{
+		{"backend-1", App},
+		{"backend-2", App},
+		{"backend-3", Request},
+	} {
+		def := def
+		b.Add(&Def{
+			Name:  def.name,
+			Scope: def.scope,
+			Is:    []reflect.Type{reflect.TypeOf((*mockHandler)(nil)).Elem()},
+			Build: func(ctn Container) (interface{}, error) {
+				return &mockHandlerImpl{name: def.name}, nil
+			},
+		})
+	}
+
+	return b
+}
+
+func TestGetRoundRobin(t *testing.T) {
+	app, _ := newBalancedTestBuilder().Build()
+	typ := reflect.TypeOf((*mockHandler)(nil)).Elem()
+
+	names := []string{}
+	for i := 0; i < 4; i++ {
+		obj, err := app.GetRoundRobin(typ)
+		require.Nil(t, err)
+		names = append(names, obj.(*mockHandlerImpl).name)
+	}
+	require.Equal(t, []string{"backend-1", "backend-2", "backend-1", "backend-2"}, names)
+
+	request, _ := app.SubContainer()
+
+	names = []string{}
+	for i := 0; i < 3; i++ {
+		obj, err := request.GetRoundRobin(typ)
+		require.Nil(t, err)
+		names = append(names, obj.(*mockHandlerImpl).name)
+	}
+	require.Equal(t, []string{"backend-1", "backend-2", "backend-3"}, names)
+
+	_, err := app.GetRoundRobin(reflect.TypeOf(""))
+	require.NotNil(t, err)
+}
+
+func TestGetRoundRobinConcurrency(t *testing.T) {
+	app, _ := newBalancedTestBuilder().Build()
+	typ := reflect.TypeOf((*mockHandler)(nil)).Elem()
+
+	var m sync.Mutex
+	counts := map[string]int{}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			obj, _ := app.GetRoundRobin(typ)
+			m.Lock()
+			counts[obj.(*mockHandlerImpl).name]++
+			m.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	require.Equal(t, map[string]int{"backend-1": 50, "backend-2": 50}, counts)
+}
+
+func TestGetRandom(t *testing.T) {
+	app, _ := newBalancedTestBuilder().Build()
+	typ := reflect.TypeOf((*mockHandler)(nil)).Elem()
+
+	for i := 0; i < 20; i++ {
+		obj, err := app.GetRandom(typ)
+		require.Nil(t, err)
+		require.Contains(t, []string{"backend-1", "backend-2"}, obj.(*mockHandlerImpl).name)
+	}
+
+	_, err := app.GetRandom(reflect.TypeOf(""))
+	require.NotNil(t, err)
+}
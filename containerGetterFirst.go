@@ -0,0 +1,79 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// GetFirst tries to retrieve an object with each of the given keys, in order,
+// and returns the first object that can be retrieved.
+// The keys are the ones accepted by SafeGet (a name, a definition, an index or a type).
+//
+// A key is skipped if it does not match any definition,
+// or if the definition scope is not reachable from this Container.
+// If the definition exists but its object can not be built, GetFirst stops and returns the error,
+// so that a broken object is not silently replaced by the next one.
+// Use GetFirstSkippingBuildErrors to try the next keys in this case.
+//
+// If no key can be used, the returned error contains the reason why each key was skipped.
+func (ctn Container) GetFirst(keys ...interface{}) (interface{}, error) {
+	return ctn.getFirst(keys, false)
+}
+
+// GetFirstSkippingBuildErrors works like GetFirst,
+// but it also tries the next keys when an object can not be built.
+func (ctn Container) GetFirstSkippingBuildErrors(keys ...interface{}) (interface{}, error) {
+	return ctn.getFirst(keys, true)
+}
+
+func (ctn Container) getFirst(keys []interface{}, skipBuildErrors bool) (interface{}, error) {
+	errBuilder := &multiErrBuilder{}
+
+	for _, key := range keys {
+		if err := ctn.checkKeyIsReachable(key); err != nil {
+			errBuilder.Add(err)
+			continue
+		}
+
+		obj, err := ctn.SafeGet(key)
+		if err == nil {
+			return obj, nil
+		}
+		if !skipBuildErrors {
+			return nil, err
+		}
+
+		errBuilder.Add(err)
+	}
+
+	if err := errBuilder.Build(); err != nil {
+		return nil, fmt.Errorf("could not get any of the %d keys%s: %w", len(keys), ctn.core.nameSuffix(), err)
+	}
+
+	return nil, fmt.Errorf("could not get an object%s because no key was given", ctn.core.nameSuffix())
+}
+
+// checkKeyIsReachable returns an error if the key does not match a definition
+// whose object can be retrieved from this Container.
+// The slice types are accepted if their element type is defined, as they can be built by SafeGet.
+func (ctn Container) checkKeyIsReachable(key interface{}) error {
+	index, err := ctn.core.resolveIndex(key)
+	if err != nil {
+		if typ, ok := key.(reflect.Type); ok && typ.Kind() == reflect.Slice && len(ctn.core.indexesByType[typ.Elem()]) > 0 {
+			return nil
+		}
+		return err
+	}
+
+	if ctn.core.definitionScopeLevels[index] > ctn.core.scopeLevel {
+		return newSentinelError(
+			ErrScopeMismatch,
+			"could not get `%s`%s because it requires `%s` scope which does not match this container scope or any of its parents scope",
+			ctn.core.definitions[index].Name,
+			ctn.core.nameSuffix(),
+			ctn.core.definitions[index].Scope,
+		)
+	}
+
+	return nil
+}
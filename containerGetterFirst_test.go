@@ -0,0 +1,68 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFirst(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name: "a",
+		Is:   NewIs(&mockA{}),
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{SField: "a"}, nil
+		},
+	})
+	b.Add(&Def{
+		Name: "error",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, errors.New("build error")
+		},
+	})
+	b.Add(&Def{
+		Name:  "request",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return "request", nil
+		},
+	})
+
+	app, _ := b.Build()
+
+	obj, err := app.GetFirst("undefined", "request", reflect.TypeOf(&mockA{}), "error")
+	require.Nil(t, err)
+	require.Equal(t, "a", obj.(*mockA).SField)
+
+	obj, err = app.GetFirst("undefined", reflect.TypeOf([]*mockA{}))
+	require.Nil(t, err)
+	require.Len(t, obj, 1)
+
+	_, err = app.GetFirst("undefined", "error", "a")
+	require.NotNil(t, err, "the build errors should not be skipped")
+
+	obj, err = app.GetFirstSkippingBuildErrors("undefined", "error", "a")
+	require.Nil(t, err)
+	require.Equal(t, "a", obj.(*mockA).SField)
+
+	_, err = app.GetFirst("undefined", "request")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "undefined")
+	require.Contains(t, err.Error(), "request")
+
+	_, err = app.GetFirstSkippingBuildErrors("error")
+	require.NotNil(t, err)
+
+	_, err = app.GetFirst()
+	require.NotNil(t, err)
+
+	req, _ := app.SubContainer()
+
+	obj, err = req.GetFirst("request", "a")
+	require.Nil(t, err)
+	require.Equal(t, "request", obj)
+}
@@ -1,40 +1,109 @@
 package di
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
+	"time"
 )
 
 // buildingChan is used internally as the value of an object while it is being built.
 type buildingChan chan struct{}
 
-// buildObject wraps the Build function to recover from a panic.
-func buildObject(
-	buildFunc func(ctn Container) (interface{}, error),
-	ctn Container,
-	index int,
-	defName string,
-) (obj interface{}, err error) {
+// buildObject calls the Build or BuildWithCleanup function of the definition and recovers from a panic.
+// The returned cleanup function is always nil if the definition uses a Build function.
+func buildObject(def Def, ctn Container, index int) (obj interface{}, cleanup func() error, err error) {
+	start := time.Now()
+
+	defer func() {
+		d := time.Since(start)
+		ctn.core.config.reportBuild(def, d, err)
+		if err == nil {
+			ctn.core.config.notifyBuild(def, obj, d)
+		}
+	}()
+
 	defer func() {
 		if r := recover(); r != nil {
-			err = fmt.Errorf("could not build `%s` because the build function panicked: %+v", defName, r)
+			if rErr, ok := r.(error); ok {
+				err = fmt.Errorf("could not build `%s` because the build function panicked: %w", def.Name, rErr)
+			} else {
+				err = fmt.Errorf("could not build `%s` because the build function panicked: %+v", def.Name, r)
+			}
+		}
+		if err != nil {
+			err = &buildError{err: err}
 		}
 	}()
 
 	ctn.builtList = append(ctn.builtList, index)
+	ctn.buildStack = append(ctn.buildStack, def.Name)
+
+	if ctn.progress == nil && ctn.core.config.trackBuilds {
+		ctn.progress = &buildProgress{core: ctn.core}
+		ctn.core.config.inProgress.Store(ctn.progress, nil)
+		defer ctn.core.config.inProgress.Delete(ctn.progress)
+	}
+
+	if ctn.progress != nil {
+		defer ctn.progress.set(ctn.progress.set(ctn.buildStack))
+	}
+
+	if err = buildDependencies(def, ctn); err == nil {
+		obj, cleanup, err = buildWithTimeout(def, ctn)
+	}
+
+	if err != nil && obj != nil && errors.Is(err, ErrDegraded) {
+		ctn.core.config.warn(def, fmt.Errorf("`%s` was built in degraded mode%s: %w", def.Name, ctn.core.nameSuffix(), err))
+		err = nil
+	}
+
+	if err != nil {
+		// The error is wrapped, so that the chain of definitions is visible
+		// when the error comes from the build of a dependency.
+		return nil, nil, fmt.Errorf("could not build `%s`: %w", def.Name, err)
+	}
 
-	return buildFunc(ctn)
+	if obj, err = def.decorate(ctn, obj); err != nil {
+		if cleanup != nil {
+			cleanup()
+		}
+		return nil, nil, err
+	}
+
+	if obj, err = ctn.core.config.wrapObject(def, obj); err != nil {
+		if cleanup != nil {
+			cleanup()
+		}
+		return nil, nil, err
+	}
+
+	return obj, cleanup, nil
+}
+
+// buildDependencies retrieves the objects of the DependsOn field of the definition
+// with the Container given to its Build function, so that they are registered as dependencies.
+func buildDependencies(def Def, ctn Container) error {
+	for _, name := range def.DependsOn {
+		if _, err := ctn.SafeGet(name); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // formatBuiltOnClosedContainerError formats the error that happens when you try to build an object with a closed container.
-func formatBuiltOnClosedContainerError(def Def, closeObjectErr error) error {
+func formatBuiltOnClosedContainerError(core *containerCore, def Def, closeObjectErr error) error {
 	formattedCloseObjectErr := ""
 	if closeObjectErr != nil {
 		formattedCloseObjectErr = fmt.Sprintf(" (with an error: %+v)", closeObjectErr)
 	}
 
 	return fmt.Errorf(
-		"could not get `%s` because the container has been deleted, the object has been created and closed%s",
+		"could not get `%s`%s because %w, the object has been created and closed%s",
 		def.Name,
+		core.nameSuffix(),
+		ErrContainerClosed,
 		formattedCloseObjectErr,
 	)
 }
@@ -50,15 +119,94 @@ func formatCycleError(ctn Container, def Def) error {
 	cycle = append(cycle, def.Name)
 
 	return fmt.Errorf(
-		"could not get `%s` because there is a cycle in the object definitions (%v)",
+		"could not get `%s`%s because %w (%v)",
 		def.Name,
+		ctn.core.nameSuffix(),
+		ErrCycle,
 		cycle,
 	)
 }
 
+// formatMaxBuildDepthError formats the error that happens when too many objects are being built at the same time.
+func formatMaxBuildDepthError(ctn Container, def Def, maxDepth int) error {
+	chain := make([]string, 0, len(ctn.buildStack)+1)
+	chain = append(chain, ctn.buildStack...)
+	chain = append(chain, def.Name)
+
+	return fmt.Errorf(
+		"could not build `%s` because the build depth exceeded %d, chain: %v",
+		def.Name,
+		maxDepth,
+		chain,
+	)
+}
+
+// getSlice builds all the objects whose definitions include the element type of sliceType
+// and returns them in a slice of type sliceType.
+// Each object is retrieved with SafeGet, so each of them must be reachable from the Container scope.
+func (ctn Container) getSlice(sliceType reflect.Type) (interface{}, error) {
+	elemType := sliceType.Elem()
+
+	if len(ctn.core.indexesByType[elemType]) == 0 {
+		return nil, newSentinelError(
+			ErrNotDefined,
+			"could not get type `%s`%s because neither this type nor its element type `%s` is defined",
+			sliceType,
+			ctn.core.nameSuffix(),
+			elemType,
+		)
+	}
+
+	slice, err := ctn.buildSlice(elemType, sliceType)
+	if err != nil {
+		return nil, err
+	}
+
+	return slice.Interface(), nil
+}
+
+// buildSlice builds all the objects whose definitions include typ
+// and returns them in a slice of type sliceType.
+// The objects must be assignable to the element type of sliceType.
+func (ctn Container) buildSlice(typ reflect.Type, sliceType reflect.Type) (reflect.Value, error) {
+	elemType := sliceType.Elem()
+	indexes := ctn.core.indexesByType[typ]
+
+	slice := reflect.MakeSlice(sliceType, 0, len(indexes))
+
+	for _, index := range indexes {
+		obj, err := ctn.SafeGet(index)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("could not get type `%s` because one of its elements could not be retrieved: %w", sliceType, err)
+		}
+
+		if obj == nil {
+			slice = reflect.Append(slice, reflect.Zero(elemType))
+			continue
+		}
+
+		v := reflect.ValueOf(obj)
+
+		if !v.Type().AssignableTo(elemType) {
+			return reflect.Value{}, fmt.Errorf(
+				"could not get type `%s` because `%s` is a `%s` that can not be used as a `%s`",
+				sliceType,
+				ctn.core.definitions[index].Name,
+				v.Type(),
+				elemType,
+			)
+		}
+
+		slice = reflect.Append(slice, v)
+	}
+
+	return slice, nil
+}
+
 // Fill is similar to SafeGet but it does not return the object.
 // Instead it fills the provided object with the value returned by SafeGet.
-// The provided object must be a pointer to the value returned by SafeGet.
+// The provided object must be a pointer to the value returned by SafeGet,
+// or a pointer to a type the value can be assigned to, like an interface implemented by the value.
 // It uses reflection so it is slower than Get and SafeGet.
 // But it can be convenient in some cases where performance is not a critical factor.
 func (ctn Container) Fill(in interface{}, dst interface{}) error {
@@ -68,3 +216,34 @@ func (ctn Container) Fill(in interface{}, dst interface{}) error {
 	}
 	return fill(obj, dst)
 }
+
+// FillAll builds all the objects whose definitions include the given type in their Is field,
+// and appends them to the slice pointed by dst, in the order their definitions were inserted in the builder.
+// The objects must be assignable to the element type of the slice.
+// For example:
+//
+//	var handlers []Handler
+//	err := ctn.FillAll(reflect.TypeOf((*Handler)(nil)).Elem(), &handlers)
+//
+// Each object is retrieved with SafeGet, so each of them must be reachable from the Container scope.
+// If an object can not be retrieved, the slice is not modified and an error is returned.
+func (ctn Container) FillAll(typ reflect.Type, dst interface{}) error {
+	dstValue := reflect.ValueOf(dst)
+
+	if dstValue.Kind() != reflect.Ptr || dstValue.IsNil() || dstValue.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("the fill destination should be a pointer to a slice, but you used a `%s`", reflect.TypeOf(dst))
+	}
+
+	if len(ctn.core.indexesByType[typ]) == 0 {
+		return newSentinelError(ErrNotDefined, "could not get type `%s`%s because it is not defined", typ, ctn.core.nameSuffix())
+	}
+
+	slice, err := ctn.buildSlice(typ, dstValue.Elem().Type())
+	if err != nil {
+		return err
+	}
+
+	dstValue.Elem().Set(reflect.AppendSlice(dstValue.Elem(), slice))
+
+	return nil
+}
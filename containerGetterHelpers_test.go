@@ -1,6 +1,7 @@
 package di
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -33,3 +34,65 @@ func TestFill(t *testing.T) {
 	require.Nil(t, err)
 	require.Equal(t, 10, object)
 }
+
+func TestFillAll(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	handlerType := reflect.TypeOf((*mockHandler)(nil)).Elem()
+
+	b.Add(&Def{
+		Name: "h1",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockHandlerImpl{name: "h1"}, nil
+		},
+		Is: []reflect.Type{handlerType},
+	})
+	b.Add(&Def{
+		Name: "h2",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockHandlerImpl{name: "h2"}, nil
+		},
+		Is: []reflect.Type{handlerType},
+	})
+	b.Add(&Def{
+		Name: "not-a-handler",
+		Build: func(ctn Container) (interface{}, error) {
+			return "string", nil
+		},
+		Is: []reflect.Type{reflect.TypeOf(&mockA{})},
+	})
+
+	app, _ := b.Build()
+
+	handlers := []mockHandler{&mockHandlerImpl{name: "h0"}}
+	err := app.FillAll(handlerType, &handlers)
+	require.Nil(t, err)
+	require.Len(t, handlers, 3)
+	require.Equal(t, "h0", handlers[0].Handle())
+	require.Equal(t, "h1", handlers[1].Handle())
+	require.Equal(t, "h2", handlers[2].Handle())
+
+	var impls []*mockHandlerImpl
+	err = app.FillAll(handlerType, &impls)
+	require.Nil(t, err)
+	require.Len(t, impls, 2)
+
+	var strs []string
+	err = app.FillAll(handlerType, &strs)
+	require.NotNil(t, err, "a handler is not a string")
+	require.Nil(t, strs)
+
+	var as []*mockA
+	err = app.FillAll(reflect.TypeOf(&mockA{}), &as)
+	require.NotNil(t, err, "the object is not a *mockA")
+
+	err = app.FillAll(reflect.TypeOf(1), &strs)
+	require.NotNil(t, err, "the type is not defined")
+
+	err = app.FillAll(handlerType, handlers)
+	require.NotNil(t, err, "the destination is not a pointer")
+
+	var h mockHandler
+	err = app.FillAll(handlerType, &h)
+	require.NotNil(t, err, "the destination is not a pointer to a slice")
+}
@@ -1,6 +1,7 @@
 package di
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"sync/atomic"
@@ -17,8 +18,16 @@ import (
 //   - From its index: ctn.SafeGet(objectDef.Index()) - only with the EnhancedBuilder
 //   - From its type: ctn.SafeGet(reflect.typeOf(MyObject{})) - only if objectDef.Is includes the given type
 //     In case there are more than one definition matching the given type,
-//     the chosen one is the last definition inserted in the builder.
+//     the chosen one is the last definition inserted in the builder,
+//     unless another TypeResolution was chosen with the WithTypeResolution option.
+//   - From a slice type: ctn.SafeGet(reflect.TypeOf([]MyInterface{})) - if no definition includes the slice type,
+//     but some definitions include its element type, a slice containing all these objects is returned.
+//     The objects are built in the order their definitions were inserted in the builder.
 func (ctn Container) SafeGet(in interface{}) (interface{}, error) {
+	if ctn.core.config.dryRun != nil {
+		in = ctn.core.config.dryRun.record(ctn, in)
+	}
+
 	var index int
 
 	switch v := in.(type) {
@@ -32,40 +41,57 @@ func (ctn Container) SafeGet(in interface{}) (interface{}, error) {
 		var ok bool
 		index, ok = ctn.core.indexesByName[v]
 		if !ok {
-			return nil, fmt.Errorf("could not get `%s` because the definition does not exist", v)
+			return nil, fmt.Errorf("could not get `%s`%s because %w", v, ctn.core.nameSuffix(), ErrNotDefined)
 		}
 	case reflect.Type:
 		indexes := ctn.core.indexesByType[v]
 		if len(indexes) == 0 {
-			return nil, fmt.Errorf("could not get type `%s` because it is not defined", v)
+			if v.Kind() == reflect.Slice {
+				return ctn.getSlice(v)
+			}
+			return nil, newSentinelError(ErrNotDefined, "could not get type `%s`%s because it is not defined", v, ctn.core.nameSuffix())
+		}
+		var err error
+		if index, err = ctn.core.selectTypeIndex(v, indexes); err != nil {
+			return nil, err
 		}
-		index = indexes[len(indexes)-1]
 	}
 
 	if index < 0 || index >= len(ctn.core.definitionScopeLevels) {
-		return nil, fmt.Errorf("could not get index `%d` because it does not exist", index)
+		return nil, newSentinelError(ErrNotDefined, "could not get index `%d`%s because it does not exist", index, ctn.core.nameSuffix())
 	}
 
 	// Finding the right core.
 	inputCore := ctn.core
 	core := ctn.core
 
-	if core.definitionScopeLevels[index] != core.scopeLevel {
-		for core.definitionScopeLevels[index] != core.scopeLevel {
-			core = core.parent
-
-			if core == nil {
-				return nil, fmt.Errorf(
-					"could not get `%s` because it requires `%s` scope which does not match this container scope or any of its parents scope",
-					inputCore.definitions[index].Name,
-					inputCore.definitions[index].Scope,
-				)
-			}
+	if core.definitionScopeLevels[index] != core.scopeLevel || len(core.definitions[index].BuildForScope) > 0 {
+		core = core.storageCore(index)
+
+		if core == nil {
+			return nil, newSentinelError(
+				ErrScopeMismatch,
+				"could not get `%s`%s because it requires `%s` scope which does not match this container scope or any of its parents scope",
+				inputCore.definitions[index].Name,
+				inputCore.nameSuffix(),
+				inputCore.definitions[index].Scope,
+			)
 		}
 	}
 
+	// The deprecation warning is only sent if the object can be retrieved from this Container.
+	if inputCore.definitions[index].Deprecated != "" {
+		inputCore.warnDeprecated(index)
+	}
+
+	// An object requested by a Build function with an empty builtList
+	// comes from an unscoped retrieval, and it may be in a more specific scope than the requester.
+	if len(ctn.builtList) == 0 && len(ctn.buildStack) > 0 {
+		core.recordScopeLeak(ctn.buildStack[len(ctn.buildStack)-1], index)
+	}
+
 	if atomic.LoadInt32(&core.isBuilt[index]) == 1 {
-		return core.objects[index], nil // Try to fetch an already built object as quickly as possible.
+		return core.object(index), nil // Try to fetch an already built object as quickly as possible.
 	}
 
 	if inputCore != core {
@@ -73,43 +99,71 @@ func (ctn Container) SafeGet(in interface{}) (interface{}, error) {
 		ctn.builtList = make([]int, 0, 10) // Reset the builtList if the scope changed.
 	}
 
-	// Retrieve the definition.
+	// Retrieve the definition, with the Build function of the container scope if there is one.
 	def := core.definitions[index]
 
+	if len(def.BuildForScope) > 0 {
+		if build, ok := def.BuildForScope[core.scopes[core.scopeLevel]]; ok {
+			def.Build = build
+			def.BuildWithCleanup = nil
+		}
+	}
+
 	// Cycle detection.
 	if len(ctn.builtList) > 0 {
 		for _, builtIndex := range ctn.builtList {
 			if builtIndex == index {
-				return nil, formatCycleError(ctn, def)
+				err := formatCycleError(ctn, def)
+				core.config.logError(err)
+				return nil, err
 			}
 		}
 	}
 
+	if maxDepth := core.config.maxBuildDepth; maxDepth > 0 && len(ctn.buildStack) >= maxDepth {
+		return nil, formatMaxBuildDepthError(ctn, def, maxDepth)
+	}
+
 	// Handle unshared objects.
 	if def.Unshared {
-		obj, err := buildObject(def.Build, ctn, index, def.Name)
+		// The draining flags are atomic, so the lock is only taken if the object needs to be kept.
+		if inputCore.isDraining() {
+			return nil, formatDrainingError(inputCore, def)
+		}
 
+		obj, cleanup, err := buildObject(def, ctn, index)
 		if err != nil {
-			return nil, fmt.Errorf("could not build `%s`: %+v", def.Name, err)
+			ctn.logBuildError(err)
+			return nil, err
 		}
 
-		if def.Close == nil {
+		closeFunc := def.closeFunc(cleanup)
+
+		if closeFunc == nil {
 			return obj, nil
 		}
 
 		core.m.Lock()
 		if core.closed {
 			core.m.Unlock()
-			err := closeObject(obj, def.Close, def.Name)
-			return nil, formatBuiltOnClosedContainerError(def, err)
+			err := closeObject(obj, closeFunc, def.Name)
+			return nil, formatBuiltOnClosedContainerError(core, def, err)
 		}
-		core.unshared = append(core.unshared, obj)
-		core.unsharedIndex = append(core.unsharedIndex, index)
+		core.unshared = append(core.unshared, unsharedObject{
+			obj:     obj,
+			index:   index,
+			name:    def.Name,
+			close:   closeFunc,
+			cleanup: cleanup,
+		})
 		if len(ctn.builtList) == 0 {
 			core.dependencies.AddVertex(-len(core.unshared))
 		} else {
 			core.dependencies.AddEdge(ctn.builtList[len(ctn.builtList)-1], -len(core.unshared))
 		}
+		if ctn.warmUp != nil {
+			ctn.warmUp.record(core, -len(core.unshared))
+		}
 		core.m.Unlock()
 
 		return obj, nil
@@ -120,13 +174,13 @@ func (ctn Container) SafeGet(in interface{}) (interface{}, error) {
 	if core.closed {
 		core.m.Unlock()
 		return nil, fmt.Errorf(
-			"could not get `%s` because the container has been deleted", def.Name,
+			"could not get `%s`%s because %w", def.Name, core.nameSuffix(), ErrContainerClosed,
 		)
 	}
 
 	if atomic.LoadInt32(&core.isBuilt[index]) == 1 { // Check again if the object was created, with the lock this time.
 		core.m.Unlock()
-		return core.objects[index], nil
+		return core.object(index), nil
 	}
 
 	if building := core.building[index]; building != nil {
@@ -135,14 +189,21 @@ func (ctn Container) SafeGet(in interface{}) (interface{}, error) {
 		return ctn.SafeGet(index) // Can not get the object without calling SafeGet again as its creation may have failed.
 	}
 
+	if inputCore.isDraining() {
+		core.m.Unlock()
+		return nil, formatDrainingError(inputCore, def)
+	}
+
 	building := make(buildingChan)
 	core.building[index] = &building // Mark the object as building.
-	core.m.Unlock()                  // And release the lock as it can take a while to create the object.
+	core.startBuild()
+	core.m.Unlock() // And release the lock as it can take a while to create the object.
 
 	// Building the shared object.
-	obj, err := buildObject(def.Build, ctn, index, def.Name)
+	obj, cleanup, err := borrowOrBuildObject(def, ctn, index)
 
 	core.m.Lock()
+	core.finishBuild()
 
 	if err != nil {
 		// The object could not be created. Remove the building channel from the container
@@ -150,6 +211,7 @@ func (ctn Container) SafeGet(in interface{}) (interface{}, error) {
 		core.building[index] = nil
 		core.m.Unlock()
 		close(building)
+		ctn.logBuildError(err)
 		return nil, err
 	}
 
@@ -158,8 +220,8 @@ func (ctn Container) SafeGet(in interface{}) (interface{}, error) {
 		// The newly created object needs to be closed, and it will not be returned.
 		core.m.Unlock()
 		close(building)
-		err = closeObject(obj, def.Close, def.Name)
-		return nil, formatBuiltOnClosedContainerError(def, err)
+		err = closeObject(obj, core.config.sharedCloseFunc(context.Background(), def, cleanup), def.Name)
+		return nil, formatBuiltOnClosedContainerError(core, def, err)
 	}
 
 	if len(ctn.builtList) == 0 {
@@ -167,8 +229,12 @@ func (ctn Container) SafeGet(in interface{}) (interface{}, error) {
 	} else {
 		core.dependencies.AddEdge(ctn.builtList[len(ctn.builtList)-1], index)
 	}
-	core.objects[index] = obj
+	core.setObject(index, obj)
+	core.cleanups[index] = cleanup
 	atomic.StoreInt32(&core.isBuilt[index], 1)
+	if ctn.warmUp != nil {
+		ctn.warmUp.record(core, index)
+	}
 	core.m.Unlock()
 	close(building)
 
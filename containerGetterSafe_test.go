@@ -376,3 +376,371 @@ func TestGetterSafeConcurrentBuild(t *testing.T) {
 
 	require.Equal(t, uint64(1), atomic.LoadUint64(&numClose))
 }
+
+type mockHandler interface {
+	Handle() string
+}
+
+type mockHandlerImpl struct{ name string }
+
+func (h *mockHandlerImpl) Handle() string { return h.name }
+
+func TestGetterSafeGetTypeAcrossScopes(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	closed := []string{}
+
+	b.Add(&Def{
+		Name:  "app-c",
+		Scope: App,
+		Is:    NewIs(&mockC{}),
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockC{SField: "app-c"}, nil
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, "app-c")
+			return nil
+		},
+	})
+	b.Add(&Def{
+		Name:  "app-b",
+		Scope: App,
+		Is:    NewIs(&mockB{}),
+		Build: func(ctn Container) (interface{}, error) {
+			c, err := ctn.SafeGet(reflect.TypeOf(&mockC{}))
+			if err != nil {
+				return nil, err
+			}
+			return &mockB{CField: *c.(*mockC)}, nil
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, "app-b")
+			return nil
+		},
+	})
+	b.Add(&Def{
+		Name:  "request-a",
+		Scope: Request,
+		Is:    NewIs(&mockA{}),
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{BField: ctn.Get(reflect.TypeOf(&mockB{})).(*mockB)}, nil
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, "request-a")
+			return nil
+		},
+	})
+	b.Add(&Def{
+		Name:  "subrequest-a",
+		Scope: SubRequest,
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.SafeGet(reflect.TypeOf(&mockA{}))
+		},
+	})
+
+	app, _ := b.Build()
+	request, _ := app.SubContainer()
+	subrequest, _ := request.SubContainer()
+
+	obj, err := subrequest.SafeGet("subrequest-a")
+	require.Nil(t, err)
+
+	a := obj.(*mockA)
+	require.Equal(t, "app-c", a.BField.CField.SField)
+	require.True(t, a == request.Get("request-a"))
+	require.True(t, a.BField == app.Get("app-b"))
+
+	// The objects are stored in the container matching their scope.
+	require.Equal(t, []string{"app-c", "app-b"}, app.Snapshot().BuiltNames())
+	require.Equal(t, []string{"request-a"}, request.Snapshot().BuiltNames())
+	require.Equal(t, []string{"subrequest-a"}, subrequest.Snapshot().BuiltNames())
+
+	// Deleting the request only closes the request objects.
+	require.Nil(t, request.DeleteWithSubContainers())
+	require.Equal(t, []string{"request-a"}, closed)
+
+	require.Nil(t, app.Delete())
+	require.Equal(t, []string{"request-a", "app-b", "app-c"}, closed)
+}
+
+func TestGetterSafeGetTypeFromNarrowerScope(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name:  "request-a",
+		Scope: Request,
+		Is:    NewIs(&mockA{}),
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+	})
+	b.Add(&Def{
+		Name:  "app-b",
+		Scope: App,
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.SafeGet(reflect.TypeOf(&mockA{}))
+		},
+	})
+
+	app, _ := b.Build()
+	request, _ := app.SubContainer()
+
+	_, err := request.SafeGet("app-b")
+	require.NotNil(t, err, "an app object can not depend on a request object, even by type")
+}
+
+func TestGetterSafeGetNestedBuildError(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	rootErr := errors.New("root error")
+
+	b.Add(&Def{
+		Name: "a",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.SafeGet("b")
+		},
+	})
+	b.Add(&Def{
+		Name:     "b",
+		Unshared: true,
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.SafeGet("c")
+		},
+	})
+	b.Add(&Def{
+		Name: "c",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, rootErr
+		},
+	})
+	b.Add(&Def{
+		Name: "panic",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.Get("a"), nil
+		},
+	})
+
+	app, _ := b.Build()
+
+	_, err := app.SafeGet("a")
+	require.NotNil(t, err)
+	require.Equal(t, "could not build `a`: could not build `b`: could not build `c`: root error", err.Error())
+	require.True(t, errors.Is(err, rootErr))
+
+	_, err = app.SafeGet("panic")
+	require.NotNil(t, err)
+	require.Equal(t, "could not build `panic` because the build function panicked: could not build `a`: could not build `b`: could not build `c`: root error", err.Error())
+	require.True(t, errors.Is(err, rootErr))
+}
+
+func TestGetterSafeGetSlice(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	handlerType := reflect.TypeOf((*mockHandler)(nil)).Elem()
+
+	b.Add(&Def{
+		Name: "h1",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockHandlerImpl{name: "h1"}, nil
+		},
+		Is: []reflect.Type{handlerType},
+	})
+	b.Add(&Def{
+		Name: "h2",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockHandlerImpl{name: "h2"}, nil
+		},
+		Is: []reflect.Type{handlerType},
+	})
+	b.Add(&Def{
+		Name:  "h3",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockHandlerImpl{name: "h3"}, nil
+		},
+		Is: []reflect.Type{handlerType},
+	})
+	b.Add(&Def{
+		Name: "wrong-type",
+		Build: func(ctn Container) (interface{}, error) {
+			return "not a *mockA", nil
+		},
+		Is: NewIs(&mockA{}),
+	})
+
+	app, _ := b.Build()
+	request, _ := app.SubContainer()
+
+	obj, err := request.SafeGet(reflect.TypeOf([]mockHandler{}))
+	require.Nil(t, err)
+	handlers := obj.([]mockHandler)
+	require.Len(t, handlers, 3)
+	require.Equal(t, "h1", handlers[0].Handle())
+	require.Equal(t, "h2", handlers[1].Handle())
+	require.Equal(t, "h3", handlers[2].Handle())
+
+	// the objects are the same as the ones retrieved individually
+	require.True(t, handlers[0] == request.Get("h1"))
+
+	// h3 is not reachable from the app container
+	_, err = app.SafeGet(reflect.TypeOf([]mockHandler{}))
+	require.NotNil(t, err)
+
+	// the element type is not defined
+	_, err = app.SafeGet(reflect.TypeOf([]mockB{}))
+	require.True(t, errors.Is(err, ErrNotDefined))
+
+	// an element is not assignable to the element type
+	_, err = app.SafeGet(reflect.TypeOf([]*mockA{}))
+	require.NotNil(t, err)
+
+	require.Panics(t, func() {
+		app.Get(reflect.TypeOf([]mockB{}))
+	})
+	require.Len(t, request.Get(reflect.TypeOf([]mockHandler{})), 3)
+}
+
+func TestGetterSafeMaxBuildDepth(t *testing.T) {
+	newBuilder := func() *EnhancedBuilder {
+		b, _ := NewEnhancedBuilder()
+
+		for i, name := range []string{"o1", "o2", "o3"} {
+			next := []string{"o2", "o3", "o4"}[i]
+			scope := []string{Request, App, App}[i]
+			b.Add(&Def{
+				Name:  name,
+				Scope: scope,
+				Build: func(ctn Container) (interface{}, error) {
+					return ctn.SafeGet(next)
+				},
+			})
+		}
+		b.Add(&Def{
+			Name: "o4",
+			Build: func(ctn Container) (interface{}, error) {
+				return "o4", nil
+			},
+		})
+
+		return b
+	}
+
+	b := newBuilder()
+	app, _ := b.Build()
+	obj, err := app.UnscopedSafeGet("o1")
+	require.Nil(t, err)
+	require.Equal(t, "o4", obj)
+
+	// the depth includes the objects built in the parent container
+	b = newBuilder()
+	app, _ = b.Build(WithMaxBuildDepth(3))
+	_, err = app.UnscopedSafeGet("o1")
+	require.NotNil(t, err)
+
+	b = newBuilder()
+	app, _ = b.Build(WithMaxBuildDepth(4))
+	_, err = app.UnscopedSafeGet("o1")
+	require.Nil(t, err)
+}
+
+func TestGetterSafeMaxBuildDepthUnscopedLoop(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name:     "app-object",
+		Unshared: true,
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.UnscopedSafeGet("request-object")
+		},
+	})
+	b.Add(&Def{
+		Name:     "request-object",
+		Scope:    Request,
+		Unshared: true,
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.SafeGet("app-object")
+		},
+	})
+
+	app, _ := b.Build(WithMaxBuildDepth(20))
+
+	_, err := app.SafeGet("app-object")
+	require.NotNil(t, err)
+}
+
+func TestSafeGetBuildForScope(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	closed := []string{}
+
+	def := &Def{
+		Name: "obj",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{SField: "default"}, nil
+		},
+		BuildForScope: map[string]func(ctn Container) (interface{}, error){
+			Request: func(ctn Container) (interface{}, error) {
+				return &mockA{SField: "request"}, nil
+			},
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, obj.(*mockA).SField)
+			return nil
+		},
+	}
+	b.Add(def)
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	req1, _ := app.SubContainer()
+	req2, _ := app.SubContainer()
+	subreq, _ := req1.SubContainer()
+
+	appObj := app.Get(def).(*mockA)
+	req1Obj := req1.Get("obj").(*mockA)
+	req2Obj := req2.Get(def).(*mockA)
+	subreqObj := subreq.Get(def).(*mockA)
+
+	require.Equal(t, "default", appObj.SField)
+	require.Equal(t, "request", req1Obj.SField)
+	require.Equal(t, "request", req2Obj.SField)
+	require.True(t, req1Obj != req2Obj, "each request has its own instance")
+	require.True(t, req1Obj == subreqObj, "the sub-request uses the instance of its request")
+	require.True(t, app.Get(def) == appObj)
+
+	require.Nil(t, req2.Delete())
+	require.Equal(t, []string{"request"}, closed)
+
+	require.Nil(t, app.DeleteWithSubContainers())
+	require.Equal(t, []string{"request", "request", "default"}, closed)
+}
+
+func TestBuildForScopeValidation(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	buildFunc := func(ctn Container) (interface{}, error) { return nil, nil }
+
+	err := b.Add(NewDef(buildFunc).SetBuildForScope(map[string]func(ctn Container) (interface{}, error){"undefined": buildFunc}))
+	require.NotNil(t, err, "the scopes must exist")
+
+	err = b.Add(NewDef(buildFunc).SetScope(Request).SetBuildForScope(map[string]func(ctn Container) (interface{}, error){App: buildFunc}))
+	require.NotNil(t, err, "the scopes can not be more generic than the definition scope")
+
+	err = b.Add(NewDef(buildFunc).SetBuildForScope(map[string]func(ctn Container) (interface{}, error){Request: nil}))
+	require.NotNil(t, err, "the functions can not be nil")
+
+	err = b.Add(NewDef(buildFunc).SetPooled(true).SetBuildForScope(map[string]func(ctn Container) (interface{}, error){Request: buildFunc}))
+	require.NotNil(t, err, "a pooled definition can not use BuildForScope")
+
+	err = b.Add(NewDef(buildFunc).SetName("obj").SetBuildForScope(map[string]func(ctn Container) (interface{}, error){Request: buildFunc}))
+	require.Nil(t, err)
+
+	b.Transform(func(def Def) Def {
+		def.Scope = SubRequest
+		return def
+	})
+
+	_, err = b.Build()
+	require.NotNil(t, err, "the BuildForScope field is checked again after the transforms")
+}
@@ -0,0 +1,125 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SafeGetWithTimeout works like SafeGet, but it returns an error wrapping ErrTimeout
+// if the object is not retrieved after the duration d.
+// It protects the caller from a Build function that never returns,
+// including when the object is being built by another call to SafeGet.
+//
+// The build is not cancelled when the timeout is reached. It continues in its own goroutine.
+// A shared object built after the timeout is still stored in the Container,
+// so another call to SafeGet may retrieve it later.
+// An unshared object built after the timeout is not returned to anyone,
+// but it is closed with the Container if its definition has a Close function.
+//
+// If d is not positive, SafeGetWithTimeout is the same as SafeGet.
+func (ctn Container) SafeGetWithTimeout(in interface{}, d time.Duration) (interface{}, error) {
+	if d <= 0 {
+		return ctn.SafeGet(in)
+	}
+
+	type result struct {
+		obj interface{}
+		err error
+	}
+
+	// The channel is buffered so that the goroutine can end even if nobody reads the result.
+	res := make(chan result, 1)
+
+	go func() {
+		obj, err := ctn.SafeGet(in)
+		res <- result{obj: obj, err: err}
+	}()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case r := <-res:
+		return r.obj, r.err
+	case <-timer.C:
+		return nil, fmt.Errorf(
+			"could not get `%s`%s after %s because %w", ctn.core.keyName(in), ctn.core.nameSuffix(), d, ErrTimeout,
+		)
+	}
+}
+
+// buildWithTimeout calls buildWithRetries in its own goroutine if the definition has a BuildTimeout.
+// It returns an error wrapping ErrTimeout if the object is not built in time.
+// The build goroutine can not be stopped, so an object built after the timeout is closed right away.
+// A panic in the build goroutine is raised again in the calling goroutine, so that buildObject can recover from it.
+func buildWithTimeout(def Def, ctn Container) (interface{}, func() error, error) {
+	if def.BuildTimeout <= 0 {
+		return buildWithRetries(def, ctn)
+	}
+
+	type result struct {
+		obj     interface{}
+		cleanup func() error
+		err     error
+		panic   interface{}
+	}
+
+	// The build goroutine appends to these slices when it retrieves dependencies.
+	// They are copied so that it does not share their backing arrays with the calling goroutine.
+	ctn.builtList = append(make([]int, 0, len(ctn.builtList)+10), ctn.builtList...)
+	ctn.buildStack = append(make([]string, 0, len(ctn.buildStack)+10), ctn.buildStack...)
+
+	// The result channel is not buffered, so that the goroutine knows if the result is received.
+	// Otherwise timedOut is closed and the goroutine has to close the object itself.
+	res := make(chan result)
+	timedOut := make(chan struct{})
+
+	go func() {
+		var r result
+
+		defer func() {
+			if p := recover(); p != nil {
+				r = result{panic: p}
+			}
+
+			select {
+			case res <- r:
+			case <-timedOut:
+				closeOrphanObject(def, ctn, r.obj, r.cleanup, r.err)
+			}
+		}()
+
+		r.obj, r.cleanup, r.err = buildWithRetries(def, ctn)
+	}()
+
+	timer := time.NewTimer(def.BuildTimeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-res:
+		if r.panic != nil {
+			panic(r.panic)
+		}
+		return r.obj, r.cleanup, r.err
+	case <-timer.C:
+		close(timedOut)
+		return nil, nil, fmt.Errorf("the build did not end after %s%s: %w", def.BuildTimeout, ctn.core.nameSuffix(), ErrTimeout)
+	}
+}
+
+// closeOrphanObject closes an object built after the BuildTimeout of its definition.
+// The object is not stored in any Container, so it is closed right away.
+// The errors are given to the logger, as there is nobody to return them to.
+func closeOrphanObject(def Def, ctn Container, obj interface{}, cleanup func() error, err error) {
+	if err != nil && (obj == nil || !errors.Is(err, ErrDegraded)) {
+		return
+	}
+
+	closeFunc := ctn.core.config.sharedCloseFunc(context.Background(), def, cleanup)
+
+	if closeErr := closeObject(obj, closeFunc, def.Name); closeErr != nil {
+		ctn.core.config.logError(fmt.Errorf("could not close `%s` built after its build timeout: %w", def.Name, closeErr))
+	}
+}
@@ -0,0 +1,133 @@
+package di
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSafeGetWithTimeout(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	release := make(chan struct{})
+
+	b.Add(&Def{
+		Name: "slow",
+		Build: func(ctn Container) (interface{}, error) {
+			<-release
+			return "slow", nil
+		},
+	})
+	b.Add(&Def{
+		Name: "fast",
+		Build: func(ctn Container) (interface{}, error) {
+			return "fast", nil
+		},
+	})
+
+	app, _ := b.Build()
+
+	obj, err := app.SafeGetWithTimeout("fast", time.Second)
+	require.Nil(t, err)
+	require.Equal(t, "fast", obj)
+
+	obj, err = app.SafeGetWithTimeout("slow", 10*time.Millisecond)
+	require.Nil(t, obj)
+	require.True(t, errors.Is(err, ErrTimeout))
+	require.Contains(t, err.Error(), "`slow`")
+
+	// A second call waits for the build started by the first call.
+	_, err = app.SafeGetWithTimeout("slow", 10*time.Millisecond)
+	require.True(t, errors.Is(err, ErrTimeout))
+
+	// The build was not cancelled and the object can be retrieved once it is built.
+	close(release)
+
+	obj, err = app.SafeGetWithTimeout("slow", time.Second)
+	require.Nil(t, err)
+	require.Equal(t, "slow", obj)
+
+	_, err = app.SafeGetWithTimeout("undefined", time.Second)
+	require.True(t, errors.Is(err, ErrNotDefined))
+
+	obj, err = app.SafeGetWithTimeout("fast", 0)
+	require.Nil(t, err)
+	require.Equal(t, "fast", obj)
+}
+
+func TestBuildTimeout(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	release := make(chan struct{})
+	closed := make(chan interface{}, 1)
+
+	b.Add(&Def{
+		Name:         "slow",
+		BuildTimeout: 10 * time.Millisecond,
+		Build: func(ctn Container) (interface{}, error) {
+			<-release
+			return "slow", nil
+		},
+		Close: func(obj interface{}) error {
+			closed <- obj
+			return nil
+		},
+	})
+	b.Add(&Def{
+		Name:         "fast",
+		BuildTimeout: time.Second,
+		Build: func(ctn Container) (interface{}, error) {
+			return "fast " + ctn.Get("dependency").(string), nil
+		},
+	})
+	b.Add(&Def{
+		Name: "dependency",
+		Build: func(ctn Container) (interface{}, error) {
+			return "dependency", nil
+		},
+	})
+	b.Add(&Def{
+		Name:         "panic",
+		BuildTimeout: time.Second,
+		Build: func(ctn Container) (interface{}, error) {
+			panic("build panic")
+		},
+	})
+
+	app, _ := b.Build()
+
+	obj, err := app.SafeGet("fast")
+	require.Nil(t, err)
+	require.Equal(t, "fast dependency", obj)
+	require.Equal(t, map[string][]string{
+		"fast":       {"dependency"},
+		"dependency": {},
+	}, app.DependencyGraph(), "the dependencies should be recorded by the build goroutine")
+
+	_, err = app.SafeGet("panic")
+	require.True(t, errors.Is(err, ErrBuildFailed))
+	require.Contains(t, err.Error(), "build panic")
+
+	obj, err = app.SafeGet("slow")
+	require.Nil(t, obj)
+	require.True(t, errors.Is(err, ErrTimeout))
+	require.True(t, errors.Is(err, ErrBuildFailed))
+	require.Contains(t, err.Error(), "`slow`")
+
+	// The object built after the timeout is closed.
+	close(release)
+
+	select {
+	case obj := <-closed:
+		require.Equal(t, "slow", obj)
+	case <-time.After(time.Second):
+		t.Fatal("the object built after the timeout was not closed")
+	}
+
+	// The build can be started again.
+	obj, err = app.SafeGet("slow")
+	require.Nil(t, err)
+	require.Equal(t, "slow", obj)
+}
@@ -0,0 +1,53 @@
+//go:build go1.18
+// +build go1.18
+
+package di
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// GetTyped retrieves an object from the Container like SafeGet, and returns it as a T.
+// The object can be retrieved with any of the parameters accepted by SafeGet.
+// T can be an interface implemented by the object:
+//
+//	logger, err := di.GetTyped[Logger](ctn, "logger")
+//
+// If the object is nil, GetTyped returns the zero value of T without error.
+// If the object is not a T, it returns an error containing the actual type of the object.
+func GetTyped[T any](ctn Container, in interface{}) (T, error) {
+	var zero T
+
+	obj, err := ctn.SafeGet(in)
+	if err != nil {
+		return zero, err
+	}
+
+	if obj == nil {
+		return zero, nil
+	}
+
+	typed, ok := obj.(T)
+	if !ok {
+		return zero, fmt.Errorf(
+			"could not get `%s`%s because the object is a `%T` and not a `%s`",
+			ctn.core.keyName(in),
+			ctn.core.nameSuffix(),
+			obj,
+			reflect.TypeOf((*T)(nil)).Elem(),
+		)
+	}
+
+	return typed, nil
+}
+
+// MustGetTyped works like GetTyped, but it panics instead of returning an error, like Get.
+func MustGetTyped[T any](ctn Container, in interface{}) T {
+	obj, err := GetTyped[T](ctn, in)
+	if err != nil {
+		panic(err)
+	}
+
+	return obj
+}
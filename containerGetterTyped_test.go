@@ -0,0 +1,59 @@
+//go:build go1.18
+// +build go1.18
+
+package di
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTyped(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	require.Nil(t, b.Add(&Def{
+		Name:  "stringer",
+		Is:    NewIs(&typedStringer{}),
+		Build: func(ctn Container) (interface{}, error) { return &typedStringer{s: "value"}, nil },
+	}))
+	require.Nil(t, b.Add(&Def{
+		Name:  "nil",
+		Build: func(ctn Container) (interface{}, error) { return nil, nil },
+	}))
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	s, err := GetTyped[*typedStringer](app, "stringer")
+	require.Nil(t, err)
+	require.Equal(t, "value", s.String())
+
+	stringer, err := GetTyped[fmt.Stringer](app, reflect.TypeOf(&typedStringer{}))
+	require.Nil(t, err)
+	require.Equal(t, "value", stringer.String())
+
+	_, err = GetTyped[int](app, "stringer")
+	require.NotNil(t, err)
+	require.Equal(t, "could not get `stringer` because the object is a `*di.typedStringer` and not a `int`", err.Error())
+
+	_, err = GetTyped[int](app, "undefined")
+	require.NotNil(t, err)
+
+	i, err := GetTyped[int](app, "nil")
+	require.Nil(t, err)
+	require.Equal(t, 0, i)
+
+	require.Equal(t, "value", MustGetTyped[fmt.Stringer](app, "stringer").String())
+	require.Panics(t, func() { MustGetTyped[int](app, "stringer") })
+}
+
+type typedStringer struct {
+	s string
+}
+
+func (s *typedStringer) String() string {
+	return s.s
+}
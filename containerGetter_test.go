@@ -361,3 +361,104 @@ func TestGetterConcurrentBuild(t *testing.T) {
 
 	require.Equal(t, uint64(1), atomic.LoadUint64(&numClose))
 }
+
+func TestGetterGetOrNil(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name: "obj",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+	})
+	b.Add(&Def{
+		Name: "nil",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, nil
+		},
+	})
+	b.Add(&Def{
+		Name: "error",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, errors.New("build error")
+		},
+	})
+	b.Add(&Def{
+		Name: "panic",
+		Build: func(ctn Container) (interface{}, error) {
+			panic("build panic")
+		},
+	})
+	b.Add(&Def{
+		Name:  "request",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+	})
+
+	app, _ := b.Build()
+
+	require.NotNil(t, app.GetOrNil("obj"))
+	require.True(t, app.GetOrNil("obj") == app.Get("obj"))
+	require.Nil(t, app.GetOrNil("nil"))
+	require.Nil(t, app.GetOrNil("error"))
+	require.Nil(t, app.GetOrNil("panic"))
+	require.Nil(t, app.GetOrNil("undefined"))
+	require.Nil(t, app.GetOrNil("request"))
+
+	app.Delete()
+	require.Nil(t, app.GetOrNil("obj"))
+}
+
+func TestGetterSafeGetOr(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name: "obj",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+	})
+	b.Add(&Def{
+		Name: "nil",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, nil
+		},
+	})
+	b.Add(&Def{
+		Name: "error",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, errors.New("build error")
+		},
+	})
+	b.Add(&Def{
+		Name: "missing-dependency",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.SafeGet("undefined")
+		},
+	})
+	b.Add(&Def{
+		Name:  "request",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+	})
+
+	app, _ := b.Build()
+
+	fallback := &mockA{}
+
+	require.True(t, app.SafeGetOr("obj", fallback) == app.Get("obj"))
+	require.Nil(t, app.SafeGetOr("nil", fallback), "a nil object is not replaced by the fallback")
+	require.True(t, app.SafeGetOr("undefined", fallback) == fallback)
+	require.True(t, app.SafeGetOr("request", fallback) == fallback)
+	require.Equal(t, []mockB{}, app.SafeGetOr(reflect.TypeOf([]mockB{}), []mockB{}), "the slice type is not defined")
+
+	require.Panics(t, func() { app.SafeGetOr("error", fallback) })
+	require.Panics(t, func() { app.SafeGetOr("missing-dependency", fallback) })
+
+	req, _ := app.SubContainer()
+	require.True(t, req.SafeGetOr("request", fallback) != fallback)
+}
@@ -0,0 +1,144 @@
+package di
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// DependencyGraph returns the dependencies between the objects built by this Container.
+// The keys are the names of the definitions of the built objects,
+// and the values are the sorted names of the definitions they directly depend on.
+// The unshared objects are only included if their definition has a Close function,
+// because the other ones are not tracked by the Container.
+//
+// Only the objects stored in this Container are included, not the ones stored in its parents or its sub-containers.
+// A dependency on an object of a parent Container is not included either.
+//
+// The dependencies are the ones recorded to close the objects in the right order.
+// An edge is only recorded when the dependency is built. If the dependency had already been built
+// when it was retrieved by another object, this second edge is not included.
+func (ctn Container) DependencyGraph() map[string][]string {
+	core := ctn.core
+	deps := map[string]map[string]struct{}{}
+
+	core.m.RLock()
+
+	for index := range core.isBuilt {
+		if atomic.LoadInt32(&core.isBuilt[index]) == 1 {
+			deps[core.definitions[index].Name] = map[string]struct{}{}
+		}
+	}
+
+	for _, obj := range core.unshared {
+		if obj.index >= 0 {
+			deps[core.definitions[obj.index].Name] = map[string]struct{}{}
+		}
+	}
+
+	for _, edge := range core.dependencies.Edges() {
+		from := core.vertexDefinitionIndex(edge[0])
+		to := core.vertexDefinitionIndex(edge[1])
+
+		if from < 0 || to < 0 {
+			continue
+		}
+
+		if _, ok := deps[core.definitions[from].Name]; !ok {
+			deps[core.definitions[from].Name] = map[string]struct{}{}
+		}
+		deps[core.definitions[from].Name][core.definitions[to].Name] = struct{}{}
+	}
+
+	core.m.RUnlock()
+
+	graph := make(map[string][]string, len(deps))
+
+	for name, names := range deps {
+		graph[name] = make([]string, 0, len(names))
+		for dep := range names {
+			graph[name] = append(graph[name], dep)
+		}
+		sort.Strings(graph[name])
+	}
+
+	return graph
+}
+
+// ExportDOT writes the result of DependencyGraph in the Graphviz DOT format.
+// Each object is a node, and there is an edge from each object to its dependencies.
+// The nodes are written in alphabetical order.
+func (ctn Container) ExportDOT(w io.Writer) error {
+	graph := ctn.DependencyGraph()
+
+	names := make([]string, 0, len(graph))
+	for name := range graph {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sb := strings.Builder{}
+	sb.WriteString("digraph di {\n")
+
+	for _, name := range names {
+		sb.WriteString(fmt.Sprintf("\t%s;\n", strconv.Quote(name)))
+	}
+
+	for _, name := range names {
+		for _, dep := range graph[name] {
+			sb.WriteString(fmt.Sprintf("\t%s -> %s;\n", strconv.Quote(name), strconv.Quote(dep)))
+		}
+	}
+
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// DefReport describes a definition of a Container in the result of the Report method.
+type DefReport struct {
+	Name     string
+	Scope    string
+	Unshared bool
+	Tags     []Tag
+	// Types contains the string representation of the types in the Is field of the definition.
+	Types []string
+	// DependsOn contains the names of the dependencies of the object, as returned by DependencyGraph.
+	// It is empty if the object has not been built by the Container.
+	DependsOn []string
+}
+
+// Report describes the definitions of the Container, in the order they were inserted in the builder.
+// It can be used to document the wiring of an application, for example by encoding it in JSON.
+// The dependencies are the ones returned by DependencyGraph, so they are only known for the objects built by this Container.
+func (ctn Container) Report() []DefReport {
+	graph := ctn.DependencyGraph()
+	reports := make([]DefReport, len(ctn.core.definitions))
+
+	for i, def := range ctn.core.definitions {
+		report := DefReport{
+			Name:      def.Name,
+			Scope:     def.Scope,
+			Unshared:  def.Unshared,
+			Tags:      append([]Tag{}, def.Tags...),
+			Types:     make([]string, len(def.Is)),
+			DependsOn: []string{},
+		}
+
+		for j, typ := range def.Is {
+			report.Types[j] = typ.String()
+		}
+
+		if deps, ok := graph[def.Name]; ok {
+			report.DependsOn = deps
+		}
+
+		reports[i] = report
+	}
+
+	return reports
+}
@@ -0,0 +1,160 @@
+package di
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newDependencyGraphTestContainer(t *testing.T) Container {
+	b, _ := NewEnhancedBuilder()
+
+	require.Nil(t, b.Add(&Def{
+		Name:  "config",
+		Build: func(ctn Container) (interface{}, error) { return "config", nil },
+	}))
+	require.Nil(t, b.Add(&Def{
+		Name: "db",
+		Build: func(ctn Container) (interface{}, error) {
+			return "db " + ctn.Get("config").(string), nil
+		},
+	}))
+	require.Nil(t, b.Add(&Def{
+		Name:     "conn",
+		Unshared: true,
+		Build: func(ctn Container) (interface{}, error) {
+			return "conn " + ctn.Get("db").(string), nil
+		},
+		Close: func(obj interface{}) error { return nil },
+	}))
+	require.Nil(t, b.Add(&Def{
+		Name: "service",
+		Build: func(ctn Container) (interface{}, error) {
+			return []interface{}{ctn.Get("conn"), ctn.Get("config")}, nil
+		},
+	}))
+	require.Nil(t, b.Add(&Def{
+		Name:  "request",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.Get("service"), nil
+		},
+	}))
+	require.Nil(t, b.Add(&Def{
+		Name:  "unused",
+		Build: func(ctn Container) (interface{}, error) { return nil, nil },
+	}))
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	return app
+}
+
+func TestDependencyGraph(t *testing.T) {
+	app := newDependencyGraphTestContainer(t)
+
+	require.Equal(t, map[string][]string{}, app.DependencyGraph())
+
+	req, _ := app.SubContainer()
+	req.Get("request")
+
+	require.Equal(t, map[string][]string{
+		"config":  {},
+		"db":      {"config"},
+		"conn":    {"db"},
+		"service": {"conn"}, // config was already built, so the edge is not recorded
+	}, app.DependencyGraph())
+
+	require.Equal(t, map[string][]string{
+		"request": {},
+	}, req.DependencyGraph(), "the objects of the parent container should not be included")
+}
+
+func TestReport(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	require.Nil(t, b.Add(&Def{
+		Name:  "config",
+		Is:    []reflect.Type{reflect.TypeOf(""), reflect.TypeOf((*fmt.Stringer)(nil)).Elem()},
+		Tags:  []Tag{{Name: "tag", Args: map[string]string{"key": "value"}}},
+		Build: func(ctn Container) (interface{}, error) { return "config", nil },
+	}))
+	require.Nil(t, b.Add(&Def{
+		Name:     "db",
+		Unshared: true,
+		Build: func(ctn Container) (interface{}, error) {
+			return "db " + ctn.Get("config").(string), nil
+		},
+		Close: func(obj interface{}) error { return nil },
+	}))
+	require.Nil(t, b.Add(&Def{
+		Name:  "service",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return "service " + ctn.Get("config").(string), nil
+		},
+	}))
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	require.Equal(t, []DefReport{
+		{
+			Name:      "config",
+			Scope:     App,
+			Tags:      []Tag{{Name: "tag", Args: map[string]string{"key": "value"}}},
+			Types:     []string{"string", "fmt.Stringer"},
+			DependsOn: []string{},
+		},
+		{
+			Name:      "db",
+			Scope:     App,
+			Unshared:  true,
+			Tags:      []Tag{},
+			Types:     []string{},
+			DependsOn: []string{},
+		},
+		{
+			Name:      "service",
+			Scope:     Request,
+			Tags:      []Tag{},
+			Types:     []string{},
+			DependsOn: []string{},
+		},
+	}, app.Report())
+
+	req, _ := app.SubContainer()
+	app.Get("db")
+	req.Get("service")
+
+	report := app.Report()
+	require.Equal(t, []string{}, report[0].DependsOn)
+	require.Equal(t, []string{"config"}, report[1].DependsOn)
+	require.Equal(t, []string{}, report[2].DependsOn, "service is not stored in the app container")
+	require.Equal(t, []string{}, req.Report()[2].DependsOn, "config is stored in the parent container")
+
+	report[0].Tags[0].Name = "modified"
+	require.Equal(t, "tag", app.Report()[0].Tags[0].Name, "the tags should be copied")
+}
+
+func TestExportDOT(t *testing.T) {
+	app := newDependencyGraphTestContainer(t)
+	app.Get("service")
+
+	buf := &bytes.Buffer{}
+	require.Nil(t, app.ExportDOT(buf))
+	require.Equal(t, `digraph di {
+	"config";
+	"conn";
+	"db";
+	"service";
+	"conn" -> "db";
+	"db" -> "config";
+	"service" -> "conn";
+}
+`, buf.String())
+}
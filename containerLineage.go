@@ -25,6 +25,42 @@ func (ctn Container) Parent() Container {
 	return parent
 }
 
+// Root returns the Container in the most generic scope
+// from which this Container descends.
+// If the Container does not have a parent, the Container itself is returned.
+func (ctn Container) Root() Container {
+	core := ctn.core
+	for core.parent != nil {
+		core = core.parent
+	}
+	if core == ctn.core {
+		return ctn
+	}
+	return Container{
+		core:      core,
+		builtList: make([]int, 0, 10),
+	}
+}
+
+// ForkView returns a Container using the same objects and the same scope as this Container,
+// but with its own resolution chain. It is NOT a new scope: the objects retrieved with the returned Container
+// are stored in this Container, and deleting one of them deletes the other.
+//
+// Inside a Build function, the Container keeps track of the objects being built,
+// to detect cycles and to close the objects in the right order. ForkView starts a new empty chain.
+// It can be used to start independent retrievals in several goroutines.
+// The returned Container keeps the context given to WithContext.
+// But the objects retrieved with the returned Container inside a Build function are not registered
+// as dependencies of the object being built, and the cycles involving the object being built are not detected
+// (retrieving this object with the returned Container would wait forever).
+func (ctn Container) ForkView() Container {
+	return Container{
+		core:      ctn.core,
+		builtList: make([]int, 0, 10),
+		ctx:       ctn.ctx,
+	}
+}
+
 // SubContainer creates a new Container in the next sub-scope
 // that will have this Container as parent.
 func (ctn Container) SubContainer() (Container, error) {
@@ -33,29 +69,7 @@ func (ctn Container) SubContainer() (Container, error) {
 	}
 
 	child := Container{
-		core: &containerCore{
-			closed: false,
-
-			scopes:     ctn.core.scopes,
-			scopeLevel: ctn.core.scopeLevel + 1,
-
-			parent:          ctn.core,
-			children:        map[*containerCore]struct{}{},
-			unscopedChild:   nil,
-			deleteIfNoChild: false,
-
-			indexesByName:         ctn.core.indexesByName,
-			indexesByType:         ctn.core.indexesByType,
-			definitions:           ctn.core.definitions,
-			definitionScopeLevels: ctn.core.definitionScopeLevels,
-			objects:               make([]interface{}, len(ctn.core.indexesByName)),
-			isBuilt:               make([]int32, len(ctn.core.indexesByName)),
-			building:              make([]*buildingChan, len(ctn.core.indexesByName)),
-			unshared:              []interface{}{},
-			unsharedIndex:         []int{},
-
-			dependencies: newGraph(),
-		},
+		core:      newChildCore(ctn.core),
 		builtList: make([]int, 0, 10),
 	}
 
@@ -63,7 +77,7 @@ func (ctn Container) SubContainer() (Container, error) {
 
 	if ctn.core.closed {
 		ctn.core.m.Unlock()
-		return Container{}, errors.New("the container is closed")
+		return Container{}, newSentinelError(ErrContainerClosed, "the container is closed")
 	}
 
 	ctn.core.children[child.core] = struct{}{}
@@ -72,3 +86,30 @@ func (ctn Container) SubContainer() (Container, error) {
 
 	return child, nil
 }
+
+// ForEachChild calls fn on each sub-container created with the SubContainer method.
+// The list of sub-containers is retrieved when ForEachChild is called.
+// The sub-containers that are deleted before fn is called on them are skipped.
+// The sub-containers created by the unscoped getters are not included,
+// but the containers created to retry a build with the RetryInFreshContainer option of a definition are.
+func (ctn Container) ForEachChild(fn func(child Container)) {
+	ctn.core.m.RLock()
+	children := make([]*containerCore, 0, len(ctn.core.children))
+	for child := range ctn.core.children {
+		children = append(children, child)
+	}
+	ctn.core.m.RUnlock()
+
+	for _, child := range children {
+		c := Container{
+			core:      child,
+			builtList: make([]int, 0, 10),
+		}
+
+		if c.IsClosed() {
+			continue
+		}
+
+		fn(c)
+	}
+}
@@ -21,6 +21,51 @@ func TestParentContainer(t *testing.T) {
 	require.Equal(t, parent, app)
 }
 
+func TestRoot(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	app, _ := b.Build()
+	req, _ := app.SubContainer()
+	subreq, _ := req.SubContainer()
+
+	require.True(t, app.Root().core == app.core)
+	require.True(t, req.Root().core == app.core)
+	require.True(t, subreq.Root().core == app.core)
+}
+
+func TestForkView(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	var builtList, forkBuiltList []int
+
+	b.Add(&Def{
+		Name: "a",
+		Build: func(ctn Container) (interface{}, error) {
+			fork := ctn.ForkView()
+			builtList = ctn.builtList
+			forkBuiltList = fork.builtList
+			return fork.SafeGet("b")
+		},
+	})
+	b.Add(&Def{
+		Name: "b",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+	})
+
+	app, _ := b.Build()
+	req, _ := app.SubContainer()
+
+	require.True(t, req.ForkView().core == req.core)
+	require.Equal(t, Request, req.ForkView().Scope())
+
+	obj := req.Get("a")
+	require.True(t, obj == app.Get("b"))
+	require.Equal(t, []int{0}, builtList)
+	require.Equal(t, []int{}, forkBuiltList)
+}
+
 func TestSubContainerCreation(t *testing.T) {
 	var err error
 	b, _ := NewEnhancedBuilder()
@@ -67,3 +112,35 @@ func TestLineageGetterCycleError(t *testing.T) {
 	_, err = req.SafeGet("o-req")
 	require.NotNil(t, err)
 }
+
+func TestForEachChild(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	app, _ := b.Build()
+
+	req1, _ := app.SubContainer()
+	req2, _ := app.SubContainer()
+	req3, _ := app.SubContainer()
+	req3.Delete()
+	req1.SubContainer()
+
+	children := []Container{}
+
+	app.ForEachChild(func(child Container) {
+		children = append(children, child)
+	})
+
+	require.Len(t, children, 2)
+	require.ElementsMatch(t, []Container{req1, req2}, children)
+
+	// a child deleted during the iteration is skipped
+	count := 0
+
+	app.ForEachChild(func(child Container) {
+		count++
+		req1.DeleteWithSubContainers()
+		req2.DeleteWithSubContainers()
+	})
+
+	require.Equal(t, 1, count)
+}
@@ -0,0 +1,63 @@
+package di
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// newObjectPools creates a pool for each pooled definition.
+// The keys of the returned map are the definition indexes.
+func newObjectPools(definitions []Def) map[int]*sync.Pool {
+	pools := map[int]*sync.Pool{}
+
+	for index, def := range definitions {
+		if def.Pooled {
+			pools[index] = &sync.Pool{}
+		}
+	}
+
+	return pools
+}
+
+// borrowOrBuildObject works like buildObject, but if the definition is pooled,
+// it first tries to take an object from the pool.
+func borrowOrBuildObject(def Def, ctn Container, index int) (obj interface{}, cleanup func() error, err error) {
+	if pool := ctn.core.config.pools[index]; pool != nil && def.Pooled {
+		if obj := pool.Get(); obj != nil {
+			return obj, nil, nil
+		}
+	}
+
+	return buildObject(def, ctn, index)
+}
+
+// sharedCloseFunc returns the function that should be used to close a shared object built from the given definition.
+// If the definition is pooled, the returned function resets the object and puts it back in the pool.
+func (c *containerConfig) sharedCloseFunc(ctx context.Context, def Def, cleanup func() error) func(obj interface{}) error {
+	pool := c.pools[def.builderIndex]
+
+	if pool == nil || !def.Pooled {
+		return def.closeFuncWithContext(ctx, cleanup)
+	}
+
+	return func(obj interface{}) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("the Reset function panicked, the object was not put back in the pool: %+v", r)
+			}
+		}()
+
+		if obj == nil {
+			return nil
+		}
+
+		if def.Reset != nil {
+			def.Reset(obj)
+		}
+
+		pool.Put(obj)
+
+		return nil
+	}
+}
@@ -0,0 +1,102 @@
+package di
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPooledDefinition(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	built := 0
+	reset := 0
+	closed := 0
+
+	b.Add(&Def{
+		Name:   "buffer",
+		Scope:  Request,
+		Pooled: true,
+		Build: func(ctn Container) (interface{}, error) {
+			built++
+			return &mockC{}, nil
+		},
+		Reset: func(obj interface{}) {
+			reset++
+			obj.(*mockC).SField = ""
+		},
+		Close: func(obj interface{}) error {
+			closed++
+			return nil
+		},
+	})
+
+	app, _ := b.Build()
+
+	for i := 0; i < 20; i++ {
+		request, _ := app.SubContainer()
+		buffer := request.Get("buffer").(*mockC)
+		require.Equal(t, "", buffer.SField, "the object should have been reset")
+		buffer.SField = "used"
+		require.True(t, buffer == request.Get("buffer"))
+		require.Nil(t, request.Delete())
+	}
+
+	require.Less(t, built, 20, "the objects should have been reused")
+	require.Equal(t, 20, reset)
+	require.Equal(t, 0, closed)
+}
+
+func TestPooledDefinitionResetPanic(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name:   "buffer",
+		Scope:  Request,
+		Pooled: true,
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockC{}, nil
+		},
+		Reset: func(obj interface{}) {
+			panic("reset panic")
+		},
+	})
+
+	app, _ := b.Build()
+	request, _ := app.SubContainer()
+	request.Get("buffer")
+	require.NotNil(t, request.Delete())
+}
+
+func TestPooledDefinitionErrors(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	err := b.Add(&Def{
+		Name:     "unshared",
+		Pooled:   true,
+		Unshared: true,
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockC{}, nil
+		},
+	})
+	require.NotNil(t, err)
+
+	err = b.Add(&Def{
+		Name:   "cleanup",
+		Pooled: true,
+		BuildWithCleanup: func(ctn Container) (interface{}, func() error, error) {
+			return &mockC{}, nil, nil
+		},
+	})
+	require.NotNil(t, err)
+	err = b.Add(&Def{
+		Name:                  "fresh",
+		Pooled:                true,
+		Retries:               1,
+		RetryInFreshContainer: true,
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockC{}, nil
+		},
+	})
+	require.NotNil(t, err, "the fresh container of a retry would never be deleted")
+}
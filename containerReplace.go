@@ -0,0 +1,83 @@
+package di
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// ReplaceObject sets the object of a shared definition without calling its Build function.
+// It is meant to be used in tests, to inject a stub in place of a real object.
+// The definition can be given the same way as in SafeGet: by name, definition, definition pointer, index or type.
+//
+// The object is stored in the Container matching the definition scope,
+// so the definition must be in the scope of this Container or in a more generic one.
+// If an object was already built for this definition, it is closed and replaced.
+// The objects that depended on the previous object are not rebuilt.
+//
+// The new object does not depend on any other object.
+// When the Container is deleted, the Close function of the definition is called on the new object.
+// If the definition is pooled, the new object is not put in the pool, so other containers never receive it.
+func (ctn Container) ReplaceObject(in interface{}, obj interface{}) error {
+	index, err := ctn.core.resolveIndex(in)
+	if err != nil {
+		return err
+	}
+
+	def := ctn.core.definitions[index]
+
+	if def.Unshared {
+		return fmt.Errorf("could not replace `%s`%s because it is unshared", def.Name, ctn.core.nameSuffix())
+	}
+
+	core := ctn.core.storageCore(index)
+	if core == nil {
+		return fmt.Errorf(
+			"could not replace `%s`%s because it requires `%s` scope: %w",
+			def.Name, ctn.core.nameSuffix(), def.Scope, ErrScopeMismatch,
+		)
+	}
+
+	core.m.Lock()
+
+	if core.closed {
+		core.m.Unlock()
+		return fmt.Errorf("could not replace `%s`%s because %w", def.Name, core.nameSuffix(), ErrContainerClosed)
+	}
+
+	wasBuilt := atomic.LoadInt32(&core.isBuilt[index]) == 1
+
+	if !wasBuilt && core.building[index] != nil {
+		core.m.Unlock()
+		return fmt.Errorf("could not replace `%s`%s because it is being built", def.Name, core.nameSuffix())
+	}
+
+	prevObj := core.object(index)
+	prevClose := core.objectCloseFunc(context.Background(), index)
+
+	core.dependencies.RemoveVertex(index)
+	core.dependencies.AddVertex(index)
+	core.setReplacedObject(index, obj)
+	core.cleanups[index] = nil
+	atomic.StoreInt32(&core.isBuilt[index], 1)
+
+	core.m.Unlock()
+
+	if wasBuilt {
+		return closeObject(prevObj, prevClose, def.Name)
+	}
+
+	return nil
+}
+
+// Replace sets the object of the shared definition with the given name, like ReplaceObject.
+// It is meant to swap an already built object during development (e.g. to reload templates)
+// without generating a new Container. The previous object is closed with the Close function of the definition.
+//
+// The new object is stored in the Container matching the definition scope.
+// Concurrent calls to Get retrieve either the previous object or the new one.
+// It returns an error if the definition does not exist, if it is unshared,
+// or if its scope is more specific than the scope of this Container.
+func (ctn Container) Replace(name string, obj interface{}) error {
+	return ctn.ReplaceObject(name, obj)
+}
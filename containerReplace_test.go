@@ -0,0 +1,216 @@
+package di
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaceObject(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	closed := []string{}
+
+	b.Add(&Def{
+		Name: "dependency",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockC{SField: "dependency"}, nil
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, obj.(*mockC).SField)
+			return nil
+		},
+	})
+	b.Add(&Def{
+		Name: "object",
+		Build: func(ctn Container) (interface{}, error) {
+			ctn.Get("dependency")
+			return &mockC{SField: "object"}, nil
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, obj.(*mockC).SField)
+			return nil
+		},
+	})
+	b.Add(&Def{
+		Name:     "unshared",
+		Unshared: true,
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockC{}, nil
+		},
+	})
+	b.Add(&Def{
+		Name:  "request",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockC{}, nil
+		},
+	})
+
+	app, _ := b.Build()
+	request, _ := app.SubContainer()
+
+	app.Get("object")
+
+	stub := &mockC{SField: "stub"}
+	err := request.ReplaceObject("object", stub)
+	require.Nil(t, err)
+	require.Equal(t, []string{"object"}, closed)
+	require.True(t, stub == app.Get("object"))
+	require.True(t, stub == request.Get("object"))
+
+	require.NotNil(t, app.ReplaceObject("unknown", stub))
+	require.NotNil(t, app.ReplaceObject("unshared", stub))
+	require.NotNil(t, app.ReplaceObject("request", stub))
+
+	require.Nil(t, request.Delete())
+	require.Nil(t, app.Delete())
+	require.ElementsMatch(t, []string{"object", "stub", "dependency"}, closed)
+
+	require.NotNil(t, app.ReplaceObject("object", stub))
+}
+
+func TestReplaceObjectNotBuilt(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	built := false
+
+	b.Add(&Def{
+		Name: "object",
+		Build: func(ctn Container) (interface{}, error) {
+			built = true
+			return &mockC{}, nil
+		},
+	})
+
+	app, _ := b.Build()
+
+	stub := &mockC{SField: "stub"}
+	require.Nil(t, app.ReplaceObject("object", stub))
+	require.True(t, stub == app.Get("object"))
+	require.False(t, built)
+}
+
+func TestReplaceObjectConcurrency(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name: "object",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockC{}, nil
+		},
+	})
+
+	app, _ := b.Build()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			app.ReplaceObject("object", &mockC{SField: "stub"})
+		}()
+		go func() {
+			defer wg.Done()
+			app.SafeGet("object")
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestReplace(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	closed := []string{}
+
+	b.Add(&Def{
+		Name: "renderer",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockC{SField: "v1"}, nil
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, obj.(*mockC).SField)
+			return nil
+		},
+	})
+	b.Add(&Def{
+		Name:     "unshared",
+		Unshared: true,
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockC{}, nil
+		},
+	})
+	b.Add(&Def{
+		Name:  "request",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockC{}, nil
+		},
+	})
+
+	app, _ := b.Build()
+	req, _ := app.SubContainer()
+
+	require.Equal(t, "v1", app.Get("renderer").(*mockC).SField)
+
+	require.Nil(t, req.Replace("renderer", &mockC{SField: "v2"}))
+	require.Equal(t, []string{"v1"}, closed)
+	require.Equal(t, "v2", app.Get("renderer").(*mockC).SField, "the object should be stored in the app container")
+
+	require.True(t, errors.Is(app.Replace("undefined", &mockC{}), ErrNotDefined))
+	require.True(t, errors.Is(app.Replace("request", &mockC{}), ErrScopeMismatch))
+	require.NotNil(t, app.Replace("unshared", &mockC{}))
+
+	req.Delete()
+	app.Delete()
+	require.Equal(t, []string{"v1", "v2"}, closed)
+}
+
+func TestReplaceObjectPooled(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	closed := []string{}
+
+	b.Add(&Def{
+		Name:   "buffer",
+		Scope:  Request,
+		Pooled: true,
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockC{SField: "built"}, nil
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, obj.(*mockC).SField)
+			return nil
+		},
+	})
+
+	app, _ := b.Build()
+
+	stub := &mockC{SField: "stub"}
+
+	req, _ := app.SubContainer()
+	require.Nil(t, req.ReplaceObject("buffer", stub))
+	require.True(t, stub == req.Get("buffer"))
+	require.Nil(t, req.Delete())
+	require.Equal(t, []string{"stub"}, closed, "the stub should be closed instead of being put in the pool")
+
+	for i := 0; i < 3; i++ {
+		req, _ := app.SubContainer()
+		require.Equal(t, "built", req.Get("buffer").(*mockC).SField, "the stub should not be taken from the pool")
+		require.Nil(t, req.Delete())
+	}
+
+	// Replacing a stub again closes the first stub without putting it in the pool either.
+	req, _ = app.SubContainer()
+	require.Nil(t, req.ReplaceObject("buffer", stub))
+	require.Nil(t, req.ReplaceObject("buffer", &mockC{SField: "other"}))
+	require.Nil(t, req.Delete())
+	require.Equal(t, []string{"stub", "stub", "other"}, closed)
+
+	req, _ = app.SubContainer()
+	require.Equal(t, "built", req.Get("buffer").(*mockC).SField)
+}
@@ -0,0 +1,39 @@
+package di
+
+import "errors"
+
+// Reset creates a new Container from a deleted root Container.
+// The new Container has the same definitions and the same settings as the deleted one,
+// but it does not have any object. It avoids building a new Container with the builder,
+// and all the checks done by the builder, when a Container is used for a short time and then deleted.
+//
+// The Container must be a root Container (created by a builder or by Reset), and it must be deleted.
+// The definition pointers bound to the deleted Container can still be used to retrieve the objects
+// of the new Container. The name given with WithName is not kept.
+func (ctn Container) Reset() (Container, error) {
+	if ctn.core.parent != nil {
+		return Container{}, errors.New("could not reset the container because it is not a root container")
+	}
+
+	if !ctn.IsClosed() {
+		return Container{}, errors.New("could not reset the container because it has not been deleted")
+	}
+
+	if len(ctn.core.scopes) == 0 {
+		return Container{}, errors.New("could not reset the container because it was not built successfully")
+	}
+
+	core := newRootCore(
+		ctn.core.scopes,
+		ctn.core.definitions,
+		ctn.core.indexesByName,
+		ctn.core.indexesByType,
+		ctn.core.definitionScopeLevels,
+		ctn.core.config,
+	)
+
+	return Container{
+		core:      core,
+		builtList: make([]int, 0, 10),
+	}, nil
+}
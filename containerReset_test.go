@@ -0,0 +1,62 @@
+package di
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReset(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	numBuilds := 0
+	closed := 0
+
+	objDef := &Def{
+		Name: "obj",
+		Build: func(ctn Container) (interface{}, error) {
+			numBuilds++
+			return &mockA{}, nil
+		},
+		Close: func(obj interface{}) error {
+			closed++
+			return nil
+		},
+	}
+	b.Add(objDef)
+
+	app, _ := b.Build()
+
+	_, err := app.Reset()
+	require.NotNil(t, err, "the container has not been deleted")
+
+	obj1 := app.Get(objDef)
+
+	req, _ := app.SubContainer()
+	_, err = req.Reset()
+	require.NotNil(t, err, "the container is not a root container")
+
+	require.Nil(t, req.Delete())
+	require.Nil(t, app.Delete())
+	require.Equal(t, 1, closed)
+
+	app, err = app.Reset()
+	require.Nil(t, err)
+	require.False(t, app.IsClosed())
+
+	obj2 := app.Get(objDef)
+	require.NotSame(t, obj1, obj2)
+	require.Equal(t, 2, numBuilds)
+	require.Equal(t, obj2, app.Get("obj"))
+
+	req, err = app.SubContainer()
+	require.Nil(t, err)
+	require.Same(t, obj2, req.Get("obj"))
+
+	require.Nil(t, req.Delete())
+	require.Nil(t, app.Delete())
+	require.Equal(t, 2, closed)
+
+	_, err = newClosedContainer().Reset()
+	require.NotNil(t, err)
+}
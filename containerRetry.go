@@ -0,0 +1,101 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// buildWithRetries calls the Build or BuildWithCleanup function of the definition.
+// It calls it again, up to def.Retries times, while the returned error wraps ErrRetryable.
+func buildWithRetries(def Def, ctn Container) (obj interface{}, cleanup func() error, err error) {
+	for attempt := 0; ; attempt++ {
+		if def.RetryInFreshContainer && attempt > 0 {
+			obj, cleanup, err = buildInFreshContainer(def, ctn)
+		} else {
+			obj, cleanup, err = callBuildFunction(def, ctn)
+		}
+
+		if err == nil || attempt >= def.Retries || !errors.Is(err, ErrRetryable) {
+			return obj, cleanup, err
+		}
+	}
+}
+
+// callBuildFunction calls the Build or BuildWithCleanup function of the definition.
+func callBuildFunction(def Def, ctn Container) (obj interface{}, cleanup func() error, err error) {
+	if def.BuildWithCleanup != nil {
+		return def.BuildWithCleanup(ctn)
+	}
+	obj, err = def.Build(ctn)
+	return obj, nil, err
+}
+
+// buildInFreshContainer calls the build function of the definition with a new Container,
+// in the same scope and with the same parent as the given Container.
+// The new Container is registered as a child of this parent, so that it is deleted with it.
+// The new Container is deleted if the build fails. Otherwise it is deleted by the returned cleanup function.
+func buildInFreshContainer(def Def, ctn Container) (obj interface{}, cleanup func() error, err error) {
+	parent := ctn.core
+	index := ctn.builtList[len(ctn.builtList)-1]
+
+	fresh := newRootCore(
+		parent.scopes,
+		parent.definitions,
+		parent.indexesByName,
+		parent.indexesByType,
+		parent.definitionScopeLevels,
+		parent.config,
+	)
+	fresh.scopeLevel = parent.scopeLevel
+	fresh.parent = parent.parent
+	if name, _ := parent.name.Load().(string); name != "" {
+		fresh.name.Store(name)
+	}
+
+	if fresh.parent != nil {
+		fresh.parent.m.Lock()
+		if fresh.parent.closed {
+			fresh.parent.m.Unlock()
+			return nil, nil, fmt.Errorf("could not retry the build of `%s` because %w", def.Name, ErrContainerClosed)
+		}
+		fresh.parent.children[fresh] = struct{}{}
+		fresh.parent.m.Unlock()
+	}
+
+	ctn.core = fresh
+
+	deleteFresh := func() error {
+		// The object itself is not stored in the fresh Container, only its dependencies.
+		fresh.m.Lock()
+		fresh.dependencies.RemoveVertex(index)
+		fresh.m.Unlock()
+		return deleteContainerCore(context.Background(), fresh)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			deleteFresh()
+			panic(r)
+		}
+	}()
+
+	obj, cleanup, err = callBuildFunction(def, ctn)
+	if err != nil && (obj == nil || !errors.Is(err, ErrDegraded)) {
+		deleteFresh()
+		return obj, nil, err
+	}
+
+	if cleanup == nil {
+		return obj, deleteFresh, err
+	}
+
+	buildCleanup := cleanup
+
+	return obj, func() error {
+		errBuilder := &multiErrBuilder{}
+		errBuilder.Add(buildCleanup())
+		errBuilder.Add(deleteFresh())
+		return errBuilder.Build()
+	}, err
+}
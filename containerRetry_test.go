@@ -0,0 +1,182 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRetries(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	calls := 0
+
+	def := &Def{
+		Name:    "object",
+		Retries: 2,
+		Build: func(ctn Container) (interface{}, error) {
+			calls++
+			if calls < 3 {
+				return nil, fmt.Errorf("attempt %d: %w", calls, ErrRetryable)
+			}
+			return calls, nil
+		},
+	}
+	require.Nil(t, b.Add(def))
+
+	failing := &Def{
+		Name:    "failing",
+		Retries: 2,
+		Build: func(ctn Container) (interface{}, error) {
+			calls++
+			return nil, errors.New("not retryable")
+		},
+	}
+	require.Nil(t, b.Add(failing))
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	require.Equal(t, 3, app.Get(def))
+	require.Equal(t, 3, calls)
+
+	calls = 0
+	_, err = app.SafeGet(failing)
+	require.NotNil(t, err)
+	require.Equal(t, 1, calls, "only the errors wrapping ErrRetryable should be retried")
+
+	// Without enough retries, the error of the last attempt is returned.
+	b, _ = NewEnhancedBuilder()
+	calls = 0
+	require.Nil(t, b.Add(&Def{Name: "object", Retries: 1, Build: def.Build}))
+
+	app, err = b.Build()
+	require.Nil(t, err)
+
+	_, err = app.SafeGet("object")
+	require.NotNil(t, err)
+	require.True(t, errors.Is(err, ErrRetryable))
+	require.Contains(t, err.Error(), "attempt 2")
+	require.Equal(t, 2, calls)
+}
+
+func TestBuildRetriesInFreshContainer(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	events := []string{}
+	numSiblings := 0
+	calls := 0
+
+	sibling := &Def{
+		Name:  "sibling",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			numSiblings++
+			return numSiblings, nil
+		},
+		Close: func(obj interface{}) error {
+			events = append(events, fmt.Sprintf("close sibling %d", obj))
+			return nil
+		},
+	}
+	require.Nil(t, b.Add(sibling))
+
+	object := &Def{
+		Name:                  "object",
+		Scope:                 Request,
+		Retries:               1,
+		RetryInFreshContainer: true,
+		Build: func(ctn Container) (interface{}, error) {
+			calls++
+			s := ctn.Get("sibling").(int)
+			if calls == 1 {
+				return nil, ErrRetryable
+			}
+			return s * 10, nil
+		},
+		Close: func(obj interface{}) error {
+			events = append(events, fmt.Sprintf("close object %d", obj))
+			return nil
+		},
+	}
+	require.Nil(t, b.Add(object))
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	req, err := app.SubContainer()
+	require.Nil(t, err)
+
+	require.Equal(t, 20, req.Get(object))
+	require.Empty(t, events, "the first attempt uses the request container, so its sibling is kept")
+	require.Equal(t, 1, req.Get(sibling))
+
+	// The fresh container of the successful retry is a child of the app container.
+	numChildren := 0
+	app.ForEachChild(func(child Container) { numChildren++ })
+	require.Equal(t, 2, numChildren)
+
+	require.Nil(t, req.Delete())
+	require.ElementsMatch(t, []string{"close object 20", "close sibling 2", "close sibling 1"}, events)
+	require.Less(t, indexOfString(events, "close object 20"), indexOfString(events, "close sibling 2"),
+		"the object should be closed before its dependencies")
+
+	numChildren = 0
+	app.ForEachChild(func(child Container) { numChildren++ })
+	require.Equal(t, 0, numChildren, "the fresh container should be deleted with the object")
+}
+
+func TestBuildRetriesInFreshContainerFailure(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	events := []string{}
+	numSiblings := 0
+
+	b.Add(&Def{
+		Name:  "sibling",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			numSiblings++
+			return numSiblings, nil
+		},
+		Close: func(obj interface{}) error {
+			events = append(events, fmt.Sprintf("close sibling %d", obj))
+			return nil
+		},
+	})
+	b.Add(&Def{
+		Name:                  "object",
+		Scope:                 Request,
+		Retries:               2,
+		RetryInFreshContainer: true,
+		Build: func(ctn Container) (interface{}, error) {
+			ctn.Get("sibling")
+			return nil, ErrRetryable
+		},
+	})
+
+	app, _ := b.Build()
+	req, _ := app.SubContainer()
+
+	_, err := req.SafeGet("object")
+	require.True(t, errors.Is(err, ErrRetryable))
+	require.Equal(t, []string{"close sibling 2", "close sibling 3"}, events, "the containers of the failed retries should be deleted")
+
+	numChildren := 0
+	app.ForEachChild(func(child Container) { numChildren++ })
+	require.Equal(t, 1, numChildren, "only the request container should remain")
+
+	require.Nil(t, req.Delete())
+	require.Equal(t, []string{"close sibling 2", "close sibling 3", "close sibling 1"}, events)
+}
+
+func indexOfString(s []string, str string) int {
+	for i, v := range s {
+		if v == str {
+			return i
+		}
+	}
+	return -1
+}
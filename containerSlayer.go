@@ -1,6 +1,7 @@
 package di
 
 import (
+	"context"
 	"fmt"
 	"sync/atomic"
 )
@@ -12,7 +13,64 @@ import (
 // The sub-containers are deleted even if they are still used in other goroutines.
 // It can cause errors. You may want to use the Delete method instead.
 func (ctn Container) DeleteWithSubContainers() error {
-	return deleteContainerCore(ctn.core)
+	return deleteContainerCore(context.Background(), ctn.core)
+}
+
+// DeleteContext works like DeleteWithSubContainers,
+// but it stops closing the objects when the context is done.
+// It can be used to limit the time spent deleting the Container and its sub-containers.
+//
+// All the containers are marked as closed, even if some of their objects are not closed.
+// The objects that were not closed before the context was done are left as they are.
+// A Close function that is running when the context is done is not interrupted,
+// but DeleteContext does not wait for it to finish.
+// In this case, the returned error contains the context error,
+// along with the errors of the Close functions that already returned.
+func (ctn Container) DeleteContext(ctx context.Context) error {
+	return deleteContainerCore(ctx, ctn.core)
+}
+
+// DeleteOrderedByScope works like DeleteWithSubContainers,
+// but the objects are closed strictly by scope, from the most specific to the most generic.
+// All the objects of the sub-containers in a given scope are closed
+// before any object in a more generic scope, even across sibling containers.
+// Inside a Container, the objects are still closed in the order given by their dependencies.
+func (ctn Container) DeleteOrderedByScope() error {
+	errBuilder := &multiErrBuilder{}
+
+	// Mark all the containers as closed and group them by scope level.
+	clonesByLevel := map[int][]*containerCore{}
+	maxLevel := ctn.core.scopeLevel
+	root := detachContainerCore(ctn.core)
+	cores := []*containerCore{root}
+
+	for len(cores) > 0 {
+		clone := cores[0]
+		cores = cores[1:]
+
+		clonesByLevel[clone.scopeLevel] = append(clonesByLevel[clone.scopeLevel], clone)
+		if clone.scopeLevel > maxLevel {
+			maxLevel = clone.scopeLevel
+		}
+
+		for child := range clone.children {
+			cores = append(cores, detachContainerCore(child))
+		}
+		if clone.unscopedChild != nil {
+			cores = append(cores, detachContainerCore(clone.unscopedChild))
+		}
+	}
+
+	errBuilder.Add(removeFromParent(context.Background(), ctn.core, root.parent))
+
+	// Close the objects, starting with the most specific scope.
+	for level := maxLevel; level >= ctn.core.scopeLevel; level-- {
+		for _, clone := range clonesByLevel[level] {
+			errBuilder.Add(closeContainerCoreObjects(context.Background(), clone))
+		}
+	}
+
+	return errBuilder.Build()
 }
 
 // Delete works like DeleteWithSubContainers if the Container does not have any child.
@@ -30,7 +88,26 @@ func (ctn Container) Delete() error {
 
 	ctn.core.m.Unlock()
 
-	return deleteContainerCore(ctn.core)
+	return deleteContainerCore(context.Background(), ctn.core)
+}
+
+// DeleteWithContext works like Delete, but the objects are closed like with DeleteContext.
+// The context is given to the CloseWithContext functions of the definitions,
+// and the remaining objects are not closed once the context is done.
+// If the Container still has sub-containers, the deletion is postponed like with Delete,
+// and the context is not used. The Container is then deleted with the context used to delete its last sub-container.
+func (ctn Container) DeleteWithContext(ctx context.Context) error {
+	ctn.core.m.Lock()
+
+	if len(ctn.core.children) > 0 {
+		ctn.core.deleteIfNoChild = true
+		ctn.core.m.Unlock()
+		return nil
+	}
+
+	ctn.core.m.Unlock()
+
+	return deleteContainerCore(ctx, ctn.core)
 }
 
 // Clean deletes the sub-container created by UnscopedSafeGet, UnscopedGet or UnscopedFill.
@@ -41,12 +118,24 @@ func (ctn Container) Clean() error {
 	ctn.core.m.Unlock()
 
 	if unscopedChild != nil {
-		return deleteContainerCore(unscopedChild)
+		return deleteContainerCore(context.Background(), unscopedChild)
 	}
 
 	return nil
 }
 
+// Done returns a channel that is closed when the Container is deleted.
+// It can be used to stop the goroutines that use the Container,
+// for example the background work started by an http handler using the HTTPMiddleware:
+//
+//	go work(di.C(r).Done())
+//
+// Note that Delete does not delete a Container that still has sub-containers,
+// so the channel is only closed when the Container is actually deleted.
+func (ctn Container) Done() <-chan struct{} {
+	return ctn.core.done
+}
+
 // IsClosed returns true if the Container has been deleted.
 func (ctn Container) IsClosed() bool {
 	ctn.core.m.RLock()
@@ -55,9 +144,44 @@ func (ctn Container) IsClosed() bool {
 	return closed
 }
 
-func deleteContainerCore(core *containerCore) error {
+// IsDeletePending returns true if Delete was called on the Container while it still had sub-containers.
+// In this case, the Container is not deleted yet.
+// It will be deleted when its last sub-container is deleted.
+// It returns false once the Container is actually deleted.
+func (ctn Container) IsDeletePending() bool {
+	ctn.core.m.RLock()
+	pending := ctn.core.deleteIfNoChild && !ctn.core.closed
+	ctn.core.m.RUnlock()
+	return pending
+}
+
+func deleteContainerCore(ctx context.Context, core *containerCore) error {
+	clone := detachContainerCore(core)
+
+	// Delete clone.
+	errBuilder := &multiErrBuilder{}
+
+	for child := range clone.children {
+		errBuilder.Add(deleteContainerCore(ctx, child))
+	}
+
+	if clone.unscopedChild != nil {
+		errBuilder.Add(deleteContainerCore(ctx, clone.unscopedChild))
+	}
+
+	errBuilder.Add(removeFromParent(ctx, core, clone.parent))
+
+	errBuilder.Add(closeContainerCoreObjects(ctx, clone))
+
+	return errBuilder.Build()
+}
+
+// detachContainerCore marks the core as closed and returns a copy of the data needed to delete it.
+func detachContainerCore(core *containerCore) *containerCore {
 	core.m.Lock()
 	clone := &containerCore{
+		config:        core.config,
+		scopeLevel:    core.scopeLevel,
 		parent:        core.parent,
 		children:      core.children,
 		unscopedChild: core.unscopedChild,
@@ -65,10 +189,13 @@ func deleteContainerCore(core *containerCore) error {
 		indexesByType: core.indexesByType,
 		definitions:   core.definitions,
 		objects:       core.objects,
+		cleanups:      core.cleanups,
 		unshared:      core.unshared,
-		unsharedIndex: core.unsharedIndex,
 		dependencies:  core.dependencies,
 	}
+	if !core.closed {
+		close(core.done)
+	}
 	core.closed = true
 	core.m.Unlock()
 
@@ -77,55 +204,107 @@ func deleteContainerCore(core *containerCore) error {
 		atomic.StoreInt32(&core.isBuilt[i], 0)
 	}
 
-	// Delete clone.
-	errBuilder := &multiErrBuilder{}
+	return clone
+}
 
-	for child := range clone.children {
-		errBuilder.Add(deleteContainerCore(child))
+// removeFromParent removes the core from the children of its parent.
+// The parent is deleted if Delete was called on it and this was its last child.
+func removeFromParent(ctx context.Context, core *containerCore, parent *containerCore) error {
+	if parent == nil {
+		return nil
 	}
 
-	if clone.unscopedChild != nil {
-		errBuilder.Add(deleteContainerCore(clone.unscopedChild))
+	parent.m.Lock()
+
+	delete(parent.children, core)
+
+	if !parent.deleteIfNoChild || len(parent.children) > 0 {
+		parent.m.Unlock()
+		return nil
 	}
 
-	if clone.parent != nil {
-		// Remove from parent.
-		clone.parent.m.Lock()
+	parent.m.Unlock()
 
-		delete(clone.parent.children, core)
+	return deleteContainerCore(ctx, parent)
+}
 
-		if !clone.parent.deleteIfNoChild || len(clone.parent.children) > 0 {
-			clone.parent.m.Unlock()
-		} else {
-			clone.parent.m.Unlock()
-			errBuilder.Add(deleteContainerCore(clone.parent))
-		}
-	}
+// closeContainerCoreObjects closes the objects of a detached core in the right order.
+func closeContainerCoreObjects(ctx context.Context, clone *containerCore) error {
+	errBuilder := &multiErrBuilder{}
 
-	// Close objects in the right order.
 	indexes, err := clone.dependencies.TopologicalOrdering()
 	errBuilder.Add(err)
 
+	if err == nil && len(clone.config.closeHints) > 0 {
+		indexes, err = applyCloseHints(clone, indexes)
+		errBuilder.Add(err)
+	}
+
 	for _, index := range indexes {
+		if err := ctx.Err(); err != nil {
+			errBuilder.Add(fmt.Errorf("could not close all the objects because the deletion was interrupted: %w", err))
+			break
+		}
+
+		var obj interface{}
+		var closeFunc func(obj interface{}) error
+		var name string
+		var def Def
+
 		if index >= 0 {
-			errBuilder.Add(closeObject(
-				clone.objects[index],
-				clone.definitions[index].Close,
-				clone.definitions[index].Name,
-			))
+			obj = clone.object(index)
+			closeFunc = clone.objectCloseFunc(ctx, index)
+			name = clone.definitions[index].Name
+			def = clone.definitions[index]
 		} else {
-			errBuilder.Add(closeObject(
-				clone.unshared[-index-1],
-				clone.definitions[clone.unsharedIndex[-index-1]].Close,
-				clone.definitions[clone.unsharedIndex[-index-1]].Name,
-			))
+			unshared := clone.unshared[-index-1]
+			obj = unshared.obj
+			closeFunc = unshared.close
+			name = unshared.name
+			def = Def{Name: name}
+			if unshared.index >= 0 {
+				def = clone.definitions[unshared.index]
+			}
+			if unshared.index >= 0 && clone.definitions[unshared.index].CloseWithContext != nil {
+				closeFunc = clone.definitions[unshared.index].closeFuncWithContext(ctx, unshared.cleanup)
+			}
 		}
 
+		if closeFunc == nil {
+			continue
+		}
+
+		err := closeObjectWithContext(ctx, obj, closeFunc, name)
+		clone.config.reportClose(name, err)
+		clone.config.logError(err)
+		clone.config.notifyClose(def, err)
+		errBuilder.Add(err)
 	}
 
 	return errBuilder.Build()
 }
 
+// closeObjectWithContext works like closeObject,
+// but it stops waiting for the Close function if the context is done.
+func closeObjectWithContext(ctx context.Context, obj interface{}, closeFunc func(interface{}) error, defName string) error {
+	if ctx.Done() == nil || closeFunc == nil {
+		return closeObject(obj, closeFunc, defName)
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- closeObject(obj, closeFunc, defName)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("could not close `%s` because the deletion was interrupted: %w", defName, ctx.Err())
+	}
+}
+
 func closeObject(obj interface{}, closeFunc func(interface{}) error, defName string) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -1,9 +1,11 @@
 package di
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -609,3 +611,532 @@ func TestCloseOrderSafeGet(t *testing.T) {
 	require.Nil(t, err)
 	require.Equal(t, []string{"req-5#2", "req-2", "req-4", "req-5#1", "req-3", "req-1", "req-5#2", "req-4", "req-5#1", "req-3", "req-1", "app-1", "app-2"}, closed)
 }
+
+func TestDeleteContext(t *testing.T) {
+	closed := []string{}
+	wedged := make(chan struct{})
+	defer close(wedged)
+
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name: "o1",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.Get("o2"), nil
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, "o1")
+			return errors.New("o1 close error")
+		},
+	})
+	b.Add(&Def{
+		Name: "o2",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.Get("o3"), nil
+		},
+		Close: func(obj interface{}) error {
+			<-wedged
+			return nil
+		},
+	})
+	b.Add(&Def{
+		Name: "o3",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, nil
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, "o3")
+			return nil
+		},
+	})
+
+	app, _ := b.Build()
+	request, _ := app.SubContainer()
+	app.Get("o1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := app.DeleteContext(ctx)
+	require.NotNil(t, err)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+	require.Contains(t, err.Error(), "o1 close error")
+	require.Equal(t, []string{"o1"}, closed)
+	require.True(t, app.IsClosed())
+	require.True(t, request.IsClosed())
+
+	// without interruption
+	b, _ = NewEnhancedBuilder()
+	b.Add(&Def{
+		Name: "object",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, nil
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, "object")
+			return nil
+		},
+	})
+
+	app, _ = b.Build()
+	app.Get("object")
+
+	err = app.DeleteContext(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, []string{"o1", "object"}, closed)
+}
+
+func TestDeleteWithContext(t *testing.T) {
+	type ctxKey struct{}
+
+	closed := []string{}
+
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name: "shared",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.Get("unshared"), nil
+		},
+		CloseWithContext: func(ctx context.Context, obj interface{}) error {
+			closed = append(closed, fmt.Sprintf("shared %v", ctx.Value(ctxKey{})))
+			return nil
+		},
+	})
+	b.Add(&Def{
+		Name:     "unshared",
+		Unshared: true,
+		BuildWithCleanup: func(ctn Container) (interface{}, func() error, error) {
+			return nil, func() error {
+				closed = append(closed, "cleanup")
+				return nil
+			}, nil
+		},
+		CloseWithContext: func(ctx context.Context, obj interface{}) error {
+			closed = append(closed, fmt.Sprintf("unshared %v", ctx.Value(ctxKey{})))
+			return nil
+		},
+	})
+	b.Add(&Def{
+		Name: "legacy",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, nil
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, "legacy")
+			return nil
+		},
+	})
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	request, _ := app.SubContainer()
+	app.Get("legacy")
+	app.Get("shared")
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "ctx")
+
+	require.Nil(t, app.DeleteWithContext(ctx))
+	require.False(t, app.IsClosed(), "the deletion should wait for the sub-containers")
+
+	require.Nil(t, request.DeleteWithContext(ctx))
+	require.True(t, app.IsClosed())
+	require.ElementsMatch(t, []string{"legacy", "shared ctx", "unshared ctx", "cleanup"}, closed)
+
+	// Close and CloseWithContext can not be used together.
+	b, _ = NewEnhancedBuilder()
+	err = b.Add(&Def{
+		Build:            func(ctn Container) (interface{}, error) { return nil, nil },
+		Close:            func(obj interface{}) error { return nil },
+		CloseWithContext: func(ctx context.Context, obj interface{}) error { return nil },
+	})
+	require.NotNil(t, err)
+
+	// The other deletion methods use context.Background().
+	closed = []string{}
+	b, _ = NewEnhancedBuilder()
+	b.Add(&Def{
+		Name: "object",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, nil
+		},
+		CloseWithContext: func(ctx context.Context, obj interface{}) error {
+			closed = append(closed, fmt.Sprintf("object %v", ctx.Value(ctxKey{})))
+			return nil
+		},
+	})
+
+	app, _ = b.Build()
+	app.Get("object")
+	require.Nil(t, app.Delete())
+	require.Equal(t, []string{"object <nil>"}, closed)
+}
+
+func TestDeleteBuildWithCleanup(t *testing.T) {
+	cleaned := []string{}
+
+	newBuildWithCleanup := func(name string) func(ctn Container) (interface{}, func() error, error) {
+		return func(ctn Container) (interface{}, func() error, error) {
+			return name, func() error {
+				cleaned = append(cleaned, name)
+				return nil
+			}, nil
+		}
+	}
+
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name:             "shared",
+		BuildWithCleanup: newBuildWithCleanup("shared"),
+		Close: func(obj interface{}) error {
+			cleaned = append(cleaned, "shared-close")
+			return nil
+		},
+	})
+	b.Add(&Def{
+		Name:             "unshared",
+		BuildWithCleanup: newBuildWithCleanup("unshared"),
+		Unshared:         true,
+	})
+	b.Add(&Def{
+		Name: "dependent",
+		BuildWithCleanup: func(ctn Container) (interface{}, func() error, error) {
+			ctn.Get("shared")
+			ctn.Get("unshared")
+			return "dependent", func() error {
+				cleaned = append(cleaned, "dependent")
+				return errors.New("cleanup error")
+			}, nil
+		},
+	})
+	b.Add(&Def{
+		Name: "error",
+		BuildWithCleanup: func(ctn Container) (interface{}, func() error, error) {
+			return nil, func() error {
+				cleaned = append(cleaned, "error")
+				return nil
+			}, errors.New("build error")
+		},
+	})
+
+	app, _ := b.Build()
+
+	require.Equal(t, "dependent", app.Get("dependent"))
+	require.Equal(t, "shared", app.Get("shared"))
+	_, err := app.SafeGet("error")
+	require.NotNil(t, err)
+
+	err = app.Delete()
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "cleanup error")
+	require.Equal(t, "dependent", cleaned[0])
+	require.ElementsMatch(t, []string{"dependent", "shared-close", "shared", "unshared"}, cleaned)
+
+	// the Close function is called before the cleanup function
+	for i, name := range cleaned {
+		if name == "shared-close" {
+			require.Equal(t, "shared", cleaned[i+1])
+		}
+	}
+}
+
+func TestDeleteOrderedByScope(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	closed := []string{}
+
+	for _, scope := range []string{App, Request, SubRequest} {
+		scope := scope
+		b.Add(&Def{
+			Name:  scope,
+			Scope: scope,
+			Build: func(ctn Container) (interface{}, error) {
+				return scope, nil
+			},
+			Close: func(obj interface{}) error {
+				closed = append(closed, obj.(string))
+				return nil
+			},
+		})
+	}
+
+	app, _ := b.Build()
+	app.Get(App)
+
+	for i := 0; i < 2; i++ {
+		request, _ := app.SubContainer()
+		request.Get(Request)
+		subrequest, _ := request.SubContainer()
+		subrequest.Get(SubRequest)
+	}
+
+	err := app.DeleteOrderedByScope()
+	require.Nil(t, err)
+	require.True(t, app.IsClosed())
+	require.Equal(t, []string{SubRequest, SubRequest, Request, Request, App}, closed)
+}
+
+func TestDeleteOrderedByScopeRemovesFromParent(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+	app, _ := b.Build()
+	request, _ := app.SubContainer()
+	request.SubContainer()
+
+	err := request.DeleteOrderedByScope()
+	require.Nil(t, err)
+	require.True(t, request.IsClosed())
+	require.False(t, app.IsClosed())
+	require.Empty(t, app.core.children)
+}
+
+func TestDeleteWithDenseDependencyGraph(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	closed := []string{}
+
+	b.Add(&Def{
+		Name: "o1",
+		Build: func(ctn Container) (interface{}, error) {
+			return "o1", nil
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, obj.(string))
+			return nil
+		},
+	})
+	b.Add(&Def{
+		Name:     "o2",
+		Unshared: true,
+		Build: func(ctn Container) (interface{}, error) {
+			return "o2", nil
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, obj.(string))
+			return nil
+		},
+	})
+	b.Add(&Def{
+		Name: "o3",
+		Build: func(ctn Container) (interface{}, error) {
+			ctn.Get("o1")
+			ctn.Get("o2")
+			return "o3", nil
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, obj.(string))
+			return nil
+		},
+	})
+
+	app, _ := b.Build(WithDenseDependencyGraph())
+	app.Get("o3")
+
+	err := app.Delete()
+	require.Nil(t, err)
+	require.Equal(t, []string{"o3", "o2", "o1"}, closed)
+}
+
+func TestDone(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+	app, _ := b.Build()
+	request, _ := app.SubContainer()
+
+	isDone := func(ctn Container) bool {
+		select {
+		case <-ctn.Done():
+			return true
+		default:
+			return false
+		}
+	}
+
+	require.False(t, isDone(app))
+	require.False(t, isDone(request))
+
+	app.Delete()
+	require.False(t, isDone(app), "the app container still has a child")
+
+	request.Delete()
+	require.True(t, isDone(request))
+	require.True(t, isDone(app))
+
+	require.Nil(t, app.DeleteWithSubContainers(), "deleting twice should not panic")
+	require.True(t, isDone(newClosedContainer()))
+}
+
+func TestDeleteWithCloseHints(t *testing.T) {
+	for _, opt := range []BuildOption{nil, WithDenseDependencyGraph()} {
+		b, _ := NewEnhancedBuilder()
+
+		closed := []string{}
+
+		newDef := func(name string) *Def {
+			return &Def{
+				Name: name,
+				Build: func(ctn Container) (interface{}, error) {
+					return name, nil
+				},
+				Close: func(obj interface{}) error {
+					closed = append(closed, obj.(string))
+					return nil
+				},
+			}
+		}
+
+		b.Add(newDef("server").SetCloseBefore("db", "undefined"))
+		b.Add(newDef("db"))
+		b.Add(newDef("logger").SetCloseAfter("db"))
+		b.Add(newDef("other"))
+
+		app, err := b.Build(opt)
+		require.Nil(t, err)
+
+		app.Get("logger")
+		app.Get("server")
+		app.Get("db")
+
+		require.Nil(t, app.Delete())
+		require.Equal(t, []string{"server", "db", "logger"}, closed)
+	}
+}
+
+func TestBuildWithInvalidDependsOn(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	service := NewDef(func(ctn Container) (interface{}, error) { return "service", nil }).
+		SetName("service").
+		SetDependsOn("config")
+
+	b.Add(service)
+
+	_, err := b.Build()
+	require.NotNil(t, err, "config is not defined")
+
+	// The definitions are not bound to a Container when the Build fails,
+	// so the builder can still be used once the error is fixed.
+	b.Add(NewDef(func(ctn Container) (interface{}, error) { return "config", nil }).SetName("config"))
+
+	app, err := b.Build()
+	require.Nil(t, err)
+	require.Equal(t, "service", app.Get(service))
+}
+
+func TestDeleteWithDependsOn(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	closed := []string{}
+
+	newDef := func(name string) *Def {
+		return &Def{
+			Name: name,
+			Build: func(ctn Container) (interface{}, error) {
+				return name, nil
+			},
+			Close: func(obj interface{}) error {
+				closed = append(closed, obj.(string))
+				return nil
+			},
+		}
+	}
+
+	b.Add(newDef("metrics-buffer").SetDependsOn("server"))
+	b.Add(newDef("server"))
+	b.Add(newDef("request").SetScope(Request).SetDependsOn("failing"))
+	b.Add(&Def{
+		Name: "failing",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, errors.New("build error")
+		},
+	})
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	// The dependency is built first, even if the Build function does not retrieve it.
+	app.Get("metrics-buffer")
+	require.True(t, app.Snapshot().IsBuilt("server"))
+
+	req, _ := app.SubContainer()
+	_, err = req.SafeGet("request")
+	require.NotNil(t, err, "the object can not be built if a dependency can not be built")
+	require.Contains(t, err.Error(), "build error")
+
+	require.Nil(t, req.Delete())
+	require.Nil(t, app.Delete())
+	require.Equal(t, []string{"metrics-buffer", "server"}, closed)
+}
+
+func TestDeleteWithCloseHintsErrors(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(NewDef(func(ctn Container) (interface{}, error) { return nil, nil }).SetName("a").SetCloseBefore("b"))
+	b.Add(NewDef(func(ctn Container) (interface{}, error) { return nil, nil }).SetName("b").SetCloseBefore("a"))
+
+	_, err := b.Build()
+	require.NotNil(t, err, "should not be able to build a container with a cycle in the close hints")
+
+	// The definitions are not bound to a Container, so the builder can still be used once the cycle is removed.
+	b.Add(NewDef(func(ctn Container) (interface{}, error) { return nil, nil }).SetName("b"))
+
+	_, err = b.Build()
+	require.Nil(t, err)
+
+	b, _ = NewEnhancedBuilder()
+
+	closed := []string{}
+
+	b.Add(&Def{
+		Name: "a",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.Get("b"), nil
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, "a")
+			return nil
+		},
+	})
+	b.Add(&Def{
+		Name: "b",
+		Build: func(ctn Container) (interface{}, error) {
+			return "b", nil
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, "b")
+			return nil
+		},
+		CloseBefore: []string{"a"},
+	})
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	app.Get("a")
+
+	err = app.Delete()
+	require.NotNil(t, err, "the close hints conflict with the dependencies")
+	require.Equal(t, []string{"a", "b"}, closed, "the objects are still closed following the dependencies")
+}
+
+func TestIsDeletePending(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	app, _ := b.Build()
+	req1, _ := app.SubContainer()
+	req2, _ := app.SubContainer()
+
+	require.False(t, app.IsDeletePending())
+
+	require.Nil(t, app.Delete())
+	require.True(t, app.IsDeletePending())
+	require.False(t, app.IsClosed())
+
+	require.Nil(t, req1.Delete())
+	require.False(t, req1.IsDeletePending())
+	require.True(t, app.IsDeletePending())
+
+	require.Nil(t, req2.Delete())
+	require.False(t, app.IsDeletePending())
+	require.True(t, app.IsClosed())
+}
@@ -0,0 +1,108 @@
+package di
+
+import (
+	"fmt"
+)
+
+// GetOrStore retrieves an object that is not declared by a definition,
+// but that is identified by a key known only at runtime (e.g. one cache per tenant).
+// If there is already an object for this key in the Container, it is returned.
+// Otherwise, the build function is called to create the object, and the object is saved in the Container.
+// The build function is only called once per key, even if GetOrStore is called concurrently.
+// If the build function returns an error, nothing is saved and the next call to GetOrStore will try to build the object again.
+// If the Container is draining, the objects that already exist are still returned,
+// but the build function is not called and an error is returned instead.
+//
+// The closeFunc function can be nil. If it is not, it is called when the Container is deleted.
+// The objects are closed in the same order as the objects created from definitions.
+// The build and closeFunc functions are only used the first time the object is created,
+// they are ignored if the object already exists.
+//
+// The keys are not shared with the definition names and only belong to this Container.
+// The parents and sub-containers do not have access to these objects.
+func (ctn Container) GetOrStore(key string, build func() (interface{}, error), closeFunc func(obj interface{}) error) (interface{}, error) {
+	core := ctn.core
+
+	core.m.Lock()
+
+	if core.closed {
+		core.m.Unlock()
+		return nil, fmt.Errorf("could not get `%s`%s because %w", key, core.nameSuffix(), ErrContainerClosed)
+	}
+
+	if position, ok := core.stored[key]; ok {
+		obj := core.unshared[position].obj
+		core.m.Unlock()
+		return obj, nil
+	}
+
+	if building, ok := core.storing[key]; ok {
+		core.m.Unlock()
+		<-(*building)                                // Wait for the object to be created by another call to GetOrStore.
+		return ctn.GetOrStore(key, build, closeFunc) // Can not get the object without calling GetOrStore again as its creation may have failed.
+	}
+
+	if core.isDraining() {
+		core.m.Unlock()
+		return nil, formatDrainingError(core, Def{Name: key})
+	}
+
+	building := make(buildingChan)
+	core.storing[key] = &building // Mark the object as building.
+	core.m.Unlock()               // And release the lock as it can take a while to create the object.
+
+	obj, err := buildStoredObject(build, key)
+
+	core.m.Lock()
+
+	delete(core.storing, key)
+
+	if err != nil {
+		core.m.Unlock()
+		close(building)
+		return nil, err
+	}
+
+	if core.closed {
+		// The container has been deleted while the object was being built.
+		core.m.Unlock()
+		close(building)
+		err = closeObject(obj, closeFunc, key)
+		return nil, formatBuiltOnClosedContainerError(core, Def{Name: key}, err)
+	}
+
+	core.unshared = append(core.unshared, unsharedObject{
+		obj:   obj,
+		index: -1,
+		name:  key,
+		close: closeFunc,
+	})
+	core.stored[key] = len(core.unshared) - 1
+
+	if len(ctn.builtList) == 0 {
+		core.dependencies.AddVertex(-len(core.unshared))
+	} else {
+		core.dependencies.AddEdge(ctn.builtList[len(ctn.builtList)-1], -len(core.unshared))
+	}
+
+	core.m.Unlock()
+	close(building)
+
+	return obj, nil
+}
+
+// buildStoredObject calls the build function given to GetOrStore and recovers from a panic.
+func buildStoredObject(build func() (interface{}, error), key string) (obj interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("could not build `%s` because the build function panicked: %+v", key, r)
+		}
+	}()
+
+	obj, err = build()
+	if err != nil {
+		return nil, fmt.Errorf("could not build `%s`: %w", key, err)
+	}
+
+	return obj, nil
+}
@@ -0,0 +1,113 @@
+package di
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOrStore(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+	app, _ := b.Build()
+
+	var numBuild uint64
+	var numClose uint64
+
+	build := func() (interface{}, error) {
+		atomic.AddUint64(&numBuild, 1)
+		return &mockA{SField: "tenant"}, nil
+	}
+	closeFunc := func(obj interface{}) error {
+		atomic.AddUint64(&numClose, 1)
+		return nil
+	}
+
+	obj1, err := app.GetOrStore("tenant-1", build, closeFunc)
+	require.Nil(t, err)
+	obj2, err := app.GetOrStore("tenant-1", build, closeFunc)
+	require.Nil(t, err)
+	obj3, err := app.GetOrStore("tenant-2", build, nil)
+	require.Nil(t, err)
+
+	require.True(t, obj1 == obj2)
+	require.False(t, obj1 == obj3)
+	require.Equal(t, uint64(2), atomic.LoadUint64(&numBuild))
+
+	// errors are not saved
+	_, err = app.GetOrStore("error", func() (interface{}, error) {
+		return nil, errors.New("build error")
+	}, nil)
+	require.NotNil(t, err)
+	_, err = app.GetOrStore("error", func() (interface{}, error) {
+		panic("build panic")
+	}, nil)
+	require.NotNil(t, err)
+	obj, err := app.GetOrStore("error", func() (interface{}, error) {
+		return 10, nil
+	}, nil)
+	require.Nil(t, err)
+	require.Equal(t, 10, obj)
+
+	require.Nil(t, app.Delete())
+	require.Equal(t, uint64(1), atomic.LoadUint64(&numClose))
+
+	_, err = app.GetOrStore("tenant-1", build, closeFunc)
+	require.NotNil(t, err)
+}
+
+func TestGetOrStoreCloseOrder(t *testing.T) {
+	closed := []string{}
+
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name: "object",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.GetOrStore("stored", func() (interface{}, error) {
+				return "stored", nil
+			}, func(obj interface{}) error {
+				closed = append(closed, "stored")
+				return nil
+			})
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, "object")
+			return nil
+		},
+	})
+
+	app, _ := b.Build()
+
+	require.Equal(t, "stored", app.Get("object"))
+	require.Nil(t, app.Delete())
+	require.Equal(t, []string{"object", "stored"}, closed)
+}
+
+func TestGetOrStoreConcurrentBuild(t *testing.T) {
+	var numBuild uint64
+
+	b, _ := NewEnhancedBuilder()
+	app, _ := b.Build()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			app.GetOrStore("key", func() (interface{}, error) {
+				time.Sleep(100 * time.Millisecond)
+				atomic.AddUint64(&numBuild, 1)
+				return nil, nil
+			}, nil)
+			wg.Done()
+		}()
+	}
+
+	wg.Wait()
+
+	require.Equal(t, uint64(1), atomic.LoadUint64(&numBuild))
+}
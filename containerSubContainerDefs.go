@@ -0,0 +1,179 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// SubContainerWithDefs works like SubContainer, but the new Container also has the given definitions,
+// in addition to the definitions of this Container. It allows to add definitions for a single request,
+// without adding them to the builder. Their objects are closed when the sub-container is deleted.
+//
+// The definitions are added to the sub-container and to its own sub-containers.
+// They must be in the scope of the sub-container or in a more specific scope.
+// If their scope is empty, the scope of the sub-container is used.
+// They can not have the name of an existing definition, and they can not be pooled.
+// Their DependsOn fields and their Validate functions are checked like in the Build method of the EnhancedBuilder.
+// Their CloseBefore and CloseAfter fields are ignored.
+//
+// The definitions are copied. Contrary to the EnhancedBuilder, the given pointers are not bound to the Container,
+// so the objects must be retrieved by name or by type.
+func (ctn Container) SubContainerWithDefs(defs ...*Def) (Container, error) {
+	if 1+ctn.core.scopeLevel >= len(ctn.core.scopes) {
+		return Container{}, fmt.Errorf("there is no more specific scope than `%s`", ctn.core.scopes[ctn.core.scopeLevel])
+	}
+
+	core, err := newChildCoreWithDefs(ctn.core, defs)
+	if err != nil {
+		return Container{}, err
+	}
+
+	ctn.core.m.Lock()
+
+	if ctn.core.closed {
+		ctn.core.m.Unlock()
+		return Container{}, newSentinelError(ErrContainerClosed, "the container is closed")
+	}
+
+	ctn.core.children[core] = struct{}{}
+
+	ctn.core.m.Unlock()
+
+	return Container{
+		core:      core,
+		builtList: make([]int, 0, 10),
+	}, nil
+}
+
+// newChildCoreWithDefs creates the core of a Container in the next sub-scope of the given core,
+// with the definitions of the parent and the extra definitions.
+// The extra definitions are appended to the definitions of the parent, so that the indexes of the parent are still valid.
+func newChildCoreWithDefs(parent *containerCore, extraDefs []*Def) (*containerCore, error) {
+	scopeLevel := parent.scopeLevel + 1
+	numDefs := len(parent.definitions) + len(extraDefs)
+
+	definitions := make([]Def, len(parent.definitions), numDefs)
+	copy(definitions, parent.definitions)
+
+	definitionScopeLevels := make([]int, len(parent.definitionScopeLevels), numDefs)
+	copy(definitionScopeLevels, parent.definitionScopeLevels)
+
+	indexesByName := make(map[string]int, numDefs)
+	for name, index := range parent.indexesByName {
+		indexesByName[name] = index
+	}
+
+	// The slices are shared with the parent until a definition with the same type is added.
+	indexesByType := make(map[reflect.Type][]int, len(parent.indexesByType))
+	for typ, indexes := range parent.indexesByType {
+		indexesByType[typ] = indexes
+	}
+
+	for _, extraDef := range extraDefs {
+		def, level, err := newExtraDef(parent.scopes, scopeLevel, extraDef, len(definitions))
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := indexesByName[def.Name]; ok {
+			return nil, fmt.Errorf("could not add the definition `%s` to the sub-container because the name is already used", def.Name)
+		}
+
+		index := len(definitions)
+
+		definitions = append(definitions, def)
+		definitionScopeLevels = append(definitionScopeLevels, level)
+		indexesByName[def.Name] = index
+
+		for _, typ := range def.Is {
+			indexes := make([]int, len(indexesByType[typ]), len(indexesByType[typ])+1)
+			copy(indexes, indexesByType[typ])
+			indexesByType[typ] = append(indexes, index)
+		}
+	}
+
+	// The extra definitions go through the same checks as in the Build method of the EnhancedBuilder.
+	// The definitions of the parent have already been checked.
+	for index := len(parent.definitions); index < len(definitions); index++ {
+		if err := checkDefinitionDependsOn(index, definitions, indexesByName, definitionScopeLevels); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateDefinitions(definitions[len(parent.definitions):]); err != nil {
+		return nil, err
+	}
+
+	core := newRootCore(
+		parent.scopes,
+		definitions,
+		indexesByName,
+		indexesByType,
+		definitionScopeLevels,
+		parent.config,
+	)
+
+	core.scopeLevel = scopeLevel
+	core.parent = parent
+
+	return core, nil
+}
+
+// newExtraDef checks a definition given to SubContainerWithDefs and returns a copy of the definition
+// that can be stored at the given index, along with its scope level.
+func newExtraDef(scopes ScopeList, minLevel int, extraDef *Def, index int) (Def, int, error) {
+	if extraDef == nil {
+		return Def{}, 0, errors.New("could not add a nil definition to the sub-container")
+	}
+
+	def := *extraDef
+
+	if def.Name == "" {
+		def.Name = generatedNamePrefix + "sub_" + strconv.Itoa(index)
+	} else if strings.HasPrefix(def.Name, generatedNamePrefix) {
+		return Def{}, 0, errors.New("the definition name can not start by `" + generatedNamePrefix + "`")
+	}
+
+	if def.Scope == "" {
+		def.Scope = scopes[minLevel]
+	}
+
+	level := -1
+	for i, s := range scopes {
+		if s == def.Scope {
+			level = i
+		}
+	}
+
+	if level < minLevel {
+		return Def{}, 0, fmt.Errorf(
+			"could not add the definition `%s` to the sub-container because its scope `%s` is not `%s` or a more specific scope",
+			def.Name, def.Scope, scopes[minLevel],
+		)
+	}
+
+	if def.Pooled {
+		return Def{}, 0, fmt.Errorf("could not add the definition `%s` to the sub-container because it is pooled", def.Name)
+	}
+
+	if err := def.checkBuildFunctions(); err != nil {
+		return Def{}, 0, fmt.Errorf("could not add the definition `%s` to the sub-container: %w", def.Name, err)
+	}
+
+	if err := def.checkBuildForScope(scopes); err != nil {
+		return Def{}, 0, fmt.Errorf("could not add the definition `%s` to the sub-container: %w", def.Name, err)
+	}
+
+	if def.Is != nil {
+		def.Is = make([]reflect.Type, len(extraDef.Is))
+		copy(def.Is, extraDef.Is)
+	}
+
+	def.builderBound = true
+	def.builderIndex = index
+
+	return def, level, nil
+}
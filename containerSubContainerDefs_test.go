@@ -0,0 +1,150 @@
+package di
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubContainerWithDefs(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	handlerType := reflect.TypeOf((*mockHandler)(nil)).Elem()
+
+	b.Add(&Def{
+		Name: "app-handler",
+		Is:   []reflect.Type{handlerType},
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockHandlerImpl{name: "app-handler"}, nil
+		},
+	})
+	b.Add(&Def{
+		Name: "config",
+		Build: func(ctn Container) (interface{}, error) {
+			return "config", nil
+		},
+	})
+
+	app, _ := b.Build()
+
+	closed := []string{}
+
+	correlationDef := &Def{
+		Name: "correlation-id",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.Get("config").(string) + "-id", nil
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, obj.(string))
+			return nil
+		},
+	}
+
+	req, err := app.SubContainerWithDefs(
+		correlationDef,
+		&Def{
+			Name: "request-handler",
+			Is:   []reflect.Type{handlerType},
+			Build: func(ctn Container) (interface{}, error) {
+				return &mockHandlerImpl{name: "request-handler"}, nil
+			},
+		},
+		&Def{
+			Name:  "subrequest-obj",
+			Scope: SubRequest,
+			Build: func(ctn Container) (interface{}, error) {
+				return ctn.Get("correlation-id").(string) + "-sub", nil
+			},
+		},
+	)
+	require.Nil(t, err)
+	require.Equal(t, Request, req.Scope())
+	require.Equal(t, Request, req.Definitions()["correlation-id"].Scope)
+	require.Equal(t, -1, correlationDef.Index(), "the definitions are not bound")
+
+	require.Equal(t, "config-id", req.Get("correlation-id"))
+	require.Equal(t, "request-handler", req.Get(handlerType).(mockHandler).Handle())
+	require.Len(t, req.Get(reflect.TypeOf([]mockHandler{})), 2)
+
+	_, err = app.SafeGet("correlation-id")
+	require.NotNil(t, err, "the parent does not have the extra definitions")
+	require.Equal(t, "app-handler", app.Get(handlerType).(mockHandler).Handle())
+	require.Len(t, app.Get(reflect.TypeOf([]mockHandler{})), 1)
+
+	subreq, _ := req.SubContainer()
+	require.Equal(t, "config-id-sub", subreq.Get("subrequest-obj"))
+
+	_, err = req.SafeGet("subrequest-obj")
+	require.NotNil(t, err)
+
+	other, _ := app.SubContainer()
+	_, err = other.SafeGet("correlation-id")
+	require.NotNil(t, err, "the other sub-containers do not have the extra definitions")
+
+	require.Nil(t, subreq.Delete())
+	require.Nil(t, req.Delete())
+	require.Equal(t, []string{"config-id"}, closed)
+}
+
+func TestSubContainerWithDefsErrors(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+	b.Add(NewDefFor("config").SetName("config"))
+	app, _ := b.Build()
+
+	buildFunc := func(ctn Container) (interface{}, error) { return nil, nil }
+
+	_, err := app.SubContainerWithDefs(nil)
+	require.NotNil(t, err)
+
+	_, err = app.SubContainerWithDefs(NewDef(buildFunc).SetName("config"))
+	require.NotNil(t, err, "the names must be unique")
+
+	_, err = app.SubContainerWithDefs(NewDef(buildFunc).SetName("a"), NewDef(buildFunc).SetName("a"))
+	require.NotNil(t, err, "the names must be unique")
+
+	_, err = app.SubContainerWithDefs(NewDef(buildFunc).SetScope(App))
+	require.NotNil(t, err, "the scope can not be more generic than the sub-container scope")
+
+	_, err = app.SubContainerWithDefs(NewDef(buildFunc).SetScope("undefined"))
+	require.NotNil(t, err)
+
+	_, err = app.SubContainerWithDefs(NewDef(nil))
+	require.NotNil(t, err)
+
+	_, err = app.SubContainerWithDefs(NewDef(buildFunc).SetPooled(true))
+	require.NotNil(t, err)
+
+	_, err = app.SubContainerWithDefs(&Def{Name: "dep", Build: buildFunc, DependsOn: []string{"undefined"}})
+	require.NotNil(t, err, "the dependencies must be defined")
+
+	_, err = app.SubContainerWithDefs(
+		&Def{Name: "req", Build: buildFunc, DependsOn: []string{"subreq"}},
+		&Def{Name: "subreq", Scope: SubRequest, Build: buildFunc},
+	)
+	require.NotNil(t, err, "a definition can not depend on a definition in a more specific scope")
+
+	_, err = app.SubContainerWithDefs(&Def{Name: "dep", Build: buildFunc, DependsOn: []string{"config"}})
+	require.Nil(t, err)
+
+	_, err = app.SubContainerWithDefs(&Def{
+		Name:     "invalid",
+		Build:    buildFunc,
+		Validate: func(def Def) error { return errors.New("validation error") },
+	})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "validation error")
+
+	req, err := app.SubContainerWithDefs(NewDef(buildFunc), NewDef(buildFunc))
+	require.Nil(t, err)
+	require.Len(t, req.Definitions(), 3, "the names are generated")
+
+	subreq, _ := req.SubContainer()
+	_, err = subreq.SubContainerWithDefs(NewDef(buildFunc))
+	require.NotNil(t, err, "there is no sub-scope")
+
+	app.DeleteWithSubContainers()
+	_, err = app.SubContainerWithDefs(NewDef(buildFunc))
+	require.True(t, errors.Is(err, ErrContainerClosed))
+}
@@ -0,0 +1,134 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// SubContainerWith works like SubContainer, but the Build and Close functions of some definitions
+// are replaced in the new Container and its own sub-containers. The parent Container is not modified.
+// It is meant to be used in tests, to replace a few objects without building a new Container.
+//
+// Each override is matched with an existing definition by its name.
+// Its Scope must be empty or equal to the scope of the existing definition.
+// Only the Build, BuildWithCleanup, Close and CloseWithContext fields of the override are used.
+// The other fields of the existing definition are kept, including its index,
+// so the objects can still be retrieved by definition, by index or by type.
+// The overridden definitions are not pooled and do not use BuildForScope.
+//
+// If the existing definition is in a more generic scope than the new Container,
+// the overridden object is stored in the new Container instead of the parent,
+// and the Scope of the definition returned by the Definitions method of the new Container is its scope.
+// The objects of the parent are still built with the original definition.
+// An error is returned if the parent has already built the object, or if it is building it,
+// because it may have been shared with other objects.
+func (ctn Container) SubContainerWith(overrides ...*Def) (Container, error) {
+	if 1+ctn.core.scopeLevel >= len(ctn.core.scopes) {
+		return Container{}, fmt.Errorf("there is no more specific scope than `%s`", ctn.core.scopes[ctn.core.scopeLevel])
+	}
+
+	core, err := newChildCoreWithOverrides(ctn.core, overrides)
+	if err != nil {
+		return Container{}, err
+	}
+
+	ctn.core.m.Lock()
+
+	if ctn.core.closed {
+		ctn.core.m.Unlock()
+		return Container{}, newSentinelError(ErrContainerClosed, "the container is closed")
+	}
+
+	ctn.core.children[core] = struct{}{}
+
+	ctn.core.m.Unlock()
+
+	return Container{
+		core:      core,
+		builtList: make([]int, 0, 10),
+	}, nil
+}
+
+// newChildCoreWithOverrides creates the core of a Container in the next sub-scope of the given core,
+// with the definitions of the parent in which the Build and Close functions of the overrides are replaced.
+func newChildCoreWithOverrides(parent *containerCore, overrides []*Def) (*containerCore, error) {
+	scopeLevel := parent.scopeLevel + 1
+
+	definitions := make([]Def, len(parent.definitions))
+	copy(definitions, parent.definitions)
+
+	definitionScopeLevels := make([]int, len(parent.definitionScopeLevels))
+	copy(definitionScopeLevels, parent.definitionScopeLevels)
+
+	for _, override := range overrides {
+		if override == nil {
+			return nil, errors.New("could not override a nil definition")
+		}
+
+		index, ok := parent.indexesByName[override.Name]
+		if !ok {
+			return nil, fmt.Errorf("could not override `%s` because %w", override.Name, ErrNotDefined)
+		}
+
+		def := definitions[index]
+
+		if override.Scope != "" && override.Scope != def.Scope {
+			return nil, fmt.Errorf(
+				"could not override `%s` because its scope `%s` is not the scope `%s` of the existing definition",
+				override.Name, override.Scope, def.Scope,
+			)
+		}
+
+		if definitionScopeLevels[index] < scopeLevel {
+			if isBuiltOrBuilding(parent.storageCore(index), index) {
+				return nil, fmt.Errorf(
+					"could not override `%s` because it has already been built in the `%s` container",
+					override.Name, def.Scope,
+				)
+			}
+			definitionScopeLevels[index] = scopeLevel
+			def.Scope = parent.scopes[scopeLevel]
+		}
+
+		def.Build = override.Build
+		def.BuildWithCleanup = override.BuildWithCleanup
+		def.Close = override.Close
+		def.CloseWithContext = override.CloseWithContext
+		def.BuildForScope = nil
+		def.Pooled = false
+
+		if err := def.checkBuildFunctions(); err != nil {
+			return nil, fmt.Errorf("could not override `%s`: %w", override.Name, err)
+		}
+
+		definitions[index] = def
+	}
+
+	core := newRootCore(
+		parent.scopes,
+		definitions,
+		parent.indexesByName,
+		parent.indexesByType,
+		definitionScopeLevels,
+		parent.config,
+	)
+
+	core.scopeLevel = scopeLevel
+	core.parent = parent
+
+	return core, nil
+}
+
+// isBuiltOrBuilding returns true if the object at the given index is built or being built in the core.
+// The core lock is held during the check, so that an object whose build is in progress is also detected.
+func isBuiltOrBuilding(core *containerCore, index int) bool {
+	if core == nil {
+		return false
+	}
+
+	core.m.RLock()
+	defer core.m.RUnlock()
+
+	return atomic.LoadInt32(&core.isBuilt[index]) == 1 || core.building[index] != nil
+}
@@ -0,0 +1,151 @@
+package di
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubContainerWith(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	clockDef := &Def{
+		Name: "clock",
+		Build: func(ctn Container) (interface{}, error) {
+			return "real-clock", nil
+		},
+	}
+	b.Add(clockDef)
+	b.Add(&Def{
+		Name:  "request-clock",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return "real-" + ctn.Get("clock").(string), nil
+		},
+	})
+	b.Add(&Def{
+		Name: "config",
+		Build: func(ctn Container) (interface{}, error) {
+			return "config", nil
+		},
+	})
+
+	app, _ := b.Build()
+
+	closed := []string{}
+
+	req, err := app.SubContainerWith(
+		&Def{
+			Name: "clock",
+			Build: func(ctn Container) (interface{}, error) {
+				return "frozen-clock", nil
+			},
+			Close: func(obj interface{}) error {
+				closed = append(closed, obj.(string))
+				return nil
+			},
+		},
+		&Def{
+			Name:  "request-clock",
+			Scope: Request,
+			Build: func(ctn Container) (interface{}, error) {
+				return "frozen-" + ctn.Get("clock").(string), nil
+			},
+		},
+	)
+	require.Nil(t, err)
+
+	require.Equal(t, "frozen-clock", req.Get("clock"))
+	require.Equal(t, "frozen-clock", req.Get(clockDef))
+	require.Equal(t, "frozen-clock", req.Get(clockDef.Index()))
+	require.Equal(t, "frozen-frozen-clock", req.Get("request-clock"))
+	require.Equal(t, Request, req.Definitions()["clock"].Scope, "the overridden clock is stored in the request container")
+	require.Equal(t, App, app.Definitions()["clock"].Scope)
+
+	// The parent and the other sub-containers still use the original definitions.
+	require.Equal(t, "real-clock", app.Get("clock"))
+
+	other, _ := app.SubContainer()
+	require.Equal(t, "real-real-clock", other.Get("request-clock"))
+
+	// The overrides are also used by the sub-containers of the new container.
+	subReq, _ := req.SubContainer()
+	require.Equal(t, "frozen-clock", subReq.Get("clock"))
+
+	require.Nil(t, subReq.Delete())
+	require.Nil(t, req.Delete())
+	require.Equal(t, []string{"frozen-clock"}, closed)
+
+	// The clock is now built in the app container, so it can not be overridden anymore.
+	_, err = app.SubContainerWith(&Def{
+		Name: "clock",
+		Build: func(ctn Container) (interface{}, error) {
+			return "frozen-clock", nil
+		},
+	})
+	require.NotNil(t, err)
+
+	_, err = app.SubContainerWith(&Def{
+		Name: "undefined",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, nil
+		},
+	})
+	require.True(t, errors.Is(err, ErrNotDefined))
+
+	_, err = app.SubContainerWith(&Def{
+		Name:  "config",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, nil
+		},
+	})
+	require.NotNil(t, err, "the scope of the override must match the existing definition")
+
+	_, err = app.SubContainerWith(&Def{Name: "config"})
+	require.NotNil(t, err, "the override must have a Build function")
+
+	_, err = app.SubContainerWith(nil)
+	require.NotNil(t, err)
+
+	_, err = subReq.SubContainerWith()
+	require.NotNil(t, err, "there is no scope after SubRequest")
+}
+
+func TestSubContainerWithObjectBeingBuilt(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	b.Add(&Def{
+		Name: "slow",
+		Build: func(ctn Container) (interface{}, error) {
+			close(started)
+			<-release
+			return "slow", nil
+		},
+	})
+
+	app, _ := b.Build()
+
+	done := make(chan error)
+	go func() {
+		_, err := app.SafeGet("slow")
+		done <- err
+	}()
+
+	<-started
+
+	_, err := app.SubContainerWith(&Def{
+		Name: "slow",
+		Build: func(ctn Container) (interface{}, error) {
+			return "fast", nil
+		},
+	})
+	require.NotNil(t, err, "the object is being built in the app container")
+
+	close(release)
+	require.Nil(t, <-done)
+}
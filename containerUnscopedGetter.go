@@ -1,7 +1,6 @@
 package di
 
 import (
-	"errors"
 	"fmt"
 	"reflect"
 )
@@ -30,18 +29,21 @@ func (ctn Container) UnscopedSafeGet(in interface{}) (interface{}, error) {
 		var ok bool
 		index, ok = ctn.core.indexesByName[v]
 		if !ok {
-			return nil, fmt.Errorf("could not get `%s` because the definition does not exist", v)
+			return nil, fmt.Errorf("could not get `%s`%s because %w", v, ctn.core.nameSuffix(), ErrNotDefined)
 		}
 	case reflect.Type:
 		indexes := ctn.core.indexesByType[v]
 		if len(indexes) == 0 {
-			return nil, fmt.Errorf("could not get type `%s` because it is not defined", v)
+			return nil, newSentinelError(ErrNotDefined, "could not get type `%s`%s because it is not defined", v, ctn.core.nameSuffix())
+		}
+		var err error
+		if index, err = ctn.core.selectTypeIndex(v, indexes); err != nil {
+			return nil, err
 		}
-		index = indexes[len(indexes)-1]
 	}
 
 	if index < 0 || index >= len(ctn.core.definitionScopeLevels) {
-		return nil, fmt.Errorf("could not get index `%d` because it does not exist", index)
+		return nil, newSentinelError(ErrNotDefined, "could not get index `%d`%s because it does not exist", index, ctn.core.nameSuffix())
 	}
 
 	if ctn.core.definitionScopeLevels[index] <= ctn.core.scopeLevel {
@@ -50,9 +52,14 @@ func (ctn Container) UnscopedSafeGet(in interface{}) (interface{}, error) {
 
 	child, err := ctn.getUnscopedChild()
 	if err != nil {
-		return nil, fmt.Errorf("could not get `%s` because %+v", ctn.core.definitions[index].Name, err)
+		return nil, fmt.Errorf("could not get `%s`%s because %w", ctn.core.definitions[index].Name, ctn.core.nameSuffix(), err)
 	}
 
+	child.buildStack = ctn.buildStack
+	child.progress = ctn.progress
+	child.warmUp = ctn.warmUp
+	child.ctx = ctn.ctx
+
 	return child.UnscopedSafeGet(index)
 }
 
@@ -77,6 +84,28 @@ func (ctn Container) UnscopedFill(in interface{}, dst interface{}) error {
 	return fill(obj, dst)
 }
 
+// UnscopedGetAllForType works like GetAllForType, but it retrieves the objects with UnscopedSafeGet.
+// So the objects can belong to a more specific scope than the Container scope.
+// All these objects are stored in the same sub-containers,
+// and the Clean method deletes all of them when they are no longer needed.
+//
+// /!\ Do not use it inside a `Build` function, for the same reasons as UnscopedSafeGet.
+func (ctn Container) UnscopedGetAllForType(typ reflect.Type) ([]interface{}, error) {
+	indexes := ctn.core.indexesByType[typ]
+	objects := make([]interface{}, 0, len(indexes))
+
+	for _, index := range indexes {
+		obj, err := ctn.UnscopedSafeGet(index)
+		if err != nil {
+			return objects, fmt.Errorf("could not get all the objects for type `%s`: %w", typ, err)
+		}
+
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
 func (ctn Container) getUnscopedChild() (Container, error) {
 	ctn.core.m.Lock()
 	unscopedChild := ctn.core.unscopedChild
@@ -98,29 +127,7 @@ func (ctn Container) addUnscopedChild() (Container, error) {
 	}
 
 	child := Container{
-		core: &containerCore{
-			closed: false,
-
-			scopes:     ctn.core.scopes,
-			scopeLevel: ctn.core.scopeLevel + 1,
-
-			parent:          ctn.core,
-			children:        map[*containerCore]struct{}{},
-			unscopedChild:   nil,
-			deleteIfNoChild: false,
-
-			indexesByName:         ctn.core.indexesByName,
-			indexesByType:         ctn.core.indexesByType,
-			definitions:           ctn.core.definitions,
-			definitionScopeLevels: ctn.core.definitionScopeLevels,
-			objects:               make([]interface{}, len(ctn.core.indexesByName)),
-			isBuilt:               make([]int32, len(ctn.core.indexesByName)),
-			building:              make([]*buildingChan, len(ctn.core.indexesByName)),
-			unshared:              []interface{}{},
-			unsharedIndex:         []int{},
-
-			dependencies: newGraph(),
-		},
+		core:      newChildCore(ctn.core),
 		builtList: make([]int, 0, 10),
 	}
 
@@ -128,7 +135,7 @@ func (ctn Container) addUnscopedChild() (Container, error) {
 
 	if ctn.core.closed {
 		ctn.core.m.Unlock()
-		return Container{}, errors.New("the container is closed")
+		return Container{}, newSentinelError(ErrContainerClosed, "the container is closed")
 	}
 
 	ctn.core.unscopedChild = child.core
@@ -150,6 +150,48 @@ func TestUnscopedGet(t *testing.T) {
 	})
 }
 
+func TestUnscopedGetAllForType(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	handlerType := reflect.TypeOf((*mockHandler)(nil)).Elem()
+
+	closed := []string{}
+
+	for _, scope := range []string{App, Request, SubRequest} {
+		scope := scope
+		b.Add(&Def{
+			Name:  scope + "-handler",
+			Scope: scope,
+			Is:    []reflect.Type{handlerType},
+			Build: func(ctn Container) (interface{}, error) {
+				return &mockHandlerImpl{name: scope}, nil
+			},
+			Close: func(obj interface{}) error {
+				closed = append(closed, scope)
+				return nil
+			},
+		})
+	}
+
+	app, _ := b.Build()
+
+	objects, err := app.UnscopedGetAllForType(handlerType)
+	require.Nil(t, err)
+	require.Equal(t, []interface{}{
+		&mockHandlerImpl{name: App},
+		&mockHandlerImpl{name: Request},
+		&mockHandlerImpl{name: SubRequest},
+	}, objects)
+
+	objects, err = app.UnscopedGetAllForType(reflect.TypeOf(0))
+	require.Nil(t, err)
+	require.Empty(t, objects)
+
+	// Clean deletes the objects of the more specific scopes together.
+	require.Nil(t, app.Clean())
+	require.ElementsMatch(t, []string{Request, SubRequest}, closed)
+}
+
 func TestUnscopedFill(t *testing.T) {
 	b, _ := NewEnhancedBuilder()
 
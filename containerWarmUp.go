@@ -0,0 +1,60 @@
+package di
+
+import (
+	"fmt"
+	"sync"
+)
+
+// WarmUp builds the objects with the given names concurrently, one goroutine per object.
+// If no name is given, it builds all the shared objects of the most generic scope,
+// except the ones whose definition has its Lazy field set to true, like BuildEager.
+// It allows to create slow objects at startup without paying for the sum of their build times.
+//
+// The dependencies are built by the goroutines that need them.
+// When several goroutines need the same shared object, it is only built once
+// and the other goroutines wait for it, as with concurrent calls to SafeGet.
+// So the Build functions must be safe for concurrent use, which is already required by the Container.
+//
+// WarmUp waits for all the goroutines to finish.
+// It does not stop at the first error, and it returns an error containing all the build errors, in the order of the names.
+// The objects are retrieved as if ForkView was used, so WarmUp should not be called from a Build function.
+func (ctn Container) WarmUp(names ...string) error {
+	indexes := make([]int, 0, len(names))
+
+	if len(names) == 0 {
+		for index, def := range ctn.core.definitions {
+			if !def.Unshared && !def.Lazy && ctn.core.definitionScopeLevels[index] == 0 {
+				indexes = append(indexes, index)
+			}
+		}
+	}
+
+	for _, name := range names {
+		index, ok := ctn.core.indexesByName[name]
+		if !ok {
+			return fmt.Errorf("could not warm up `%s`%s because %w", name, ctn.core.nameSuffix(), ErrNotDefined)
+		}
+		indexes = append(indexes, index)
+	}
+
+	errs := make([]error, len(indexes))
+
+	var wg sync.WaitGroup
+	wg.Add(len(indexes))
+
+	for i, index := range indexes {
+		go func(i, index int) {
+			defer wg.Done()
+			_, errs[i] = ctn.ForkView().SafeGet(index)
+		}(i, index)
+	}
+
+	wg.Wait()
+
+	errBuilder := &multiErrBuilder{}
+	for _, err := range errs {
+		errBuilder.Add(err)
+	}
+
+	return errBuilder.Build()
+}
@@ -0,0 +1,87 @@
+package di
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarmUp(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	var numBuilds int32
+	started := make(chan struct{}, 3)
+	release := make(chan struct{})
+
+	slowBuild := func(ctn Container) (interface{}, error) {
+		started <- struct{}{}
+		<-release
+		ctn.Get("shared-dependency")
+		return "slow", nil
+	}
+
+	b.Add(&Def{
+		Name: "shared-dependency",
+		Build: func(ctn Container) (interface{}, error) {
+			atomic.AddInt32(&numBuilds, 1)
+			time.Sleep(10 * time.Millisecond)
+			return "dependency", nil
+		},
+	})
+	b.Add(&Def{Name: "slow-1", Build: slowBuild})
+	b.Add(&Def{Name: "slow-2", Build: slowBuild})
+	b.Add(&Def{Name: "slow-3", Build: slowBuild})
+	b.Add(&Def{
+		Name: "lazy",
+		Lazy: true,
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, errors.New("lazy objects should not be built")
+		},
+	})
+	b.Add(&Def{
+		Name:  "request",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, errors.New("request objects should not be built")
+		},
+	})
+
+	app, _ := b.Build()
+
+	go func() {
+		// The three slow objects must be building at the same time before they are released.
+		for i := 0; i < 3; i++ {
+			<-started
+		}
+		close(release)
+	}()
+
+	require.Nil(t, app.WarmUp())
+	require.Equal(t, int32(1), atomic.LoadInt32(&numBuilds))
+	require.Equal(t, []string{"shared-dependency", "slow-1", "slow-2", "slow-3"}, app.BuiltNames())
+}
+
+func TestWarmUpErrors(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	errA := errors.New("error a")
+	errB := errors.New("error b")
+
+	b.Add(&Def{Name: "a", Build: func(ctn Container) (interface{}, error) { return nil, errA }})
+	b.Add(&Def{Name: "b", Build: func(ctn Container) (interface{}, error) { return nil, errB }})
+	b.Add(&Def{Name: "c", Build: func(ctn Container) (interface{}, error) { return "c", nil }})
+
+	app, _ := b.Build()
+
+	err := app.WarmUp("c", "b", "a")
+	require.NotNil(t, err)
+	require.True(t, errors.Is(err, errA))
+	require.True(t, errors.Is(err, errB))
+	require.Equal(t, []string{"c"}, app.BuiltNames())
+
+	err = app.WarmUp("c", "undefined")
+	require.True(t, errors.Is(err, ErrNotDefined))
+}
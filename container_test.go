@@ -136,6 +136,23 @@ func TestContainerDefinitions(t *testing.T) {
 	require.Equal(t, "o2", defs["o2"].Name)
 }
 
+func TestContainerNames(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	for _, name := range []string{"o3", "o1", "o2"} {
+		b.Add(&Def{
+			Name: name,
+			Build: func(ctn Container) (interface{}, error) {
+				return nil, nil
+			},
+		})
+	}
+
+	app, _ := b.Build()
+
+	require.Equal(t, []string{"o3", "o1", "o2"}, app.Names())
+}
+
 func TestContainerNameIsDefined(t *testing.T) {
 	b, _ := NewEnhancedBuilder()
 
@@ -152,6 +169,26 @@ func TestContainerNameIsDefined(t *testing.T) {
 	require.False(t, app.NameIsDefined("o2"))
 }
 
+func TestContainerResolveName(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name: "o1",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, nil
+		},
+	})
+
+	app, _ := b.Build()
+
+	name, err := app.ResolveName("o1")
+	require.Nil(t, err)
+	require.Equal(t, "o1", name)
+
+	_, err = app.ResolveName("o2")
+	require.NotNil(t, err)
+}
+
 func TestContainerTypeIsDefined(t *testing.T) {
 	b, _ := NewEnhancedBuilder()
 
@@ -208,6 +245,44 @@ func TestContainerDefinitionsForType(t *testing.T) {
 	require.Equal(t, def1.Name, ptrTypes[0].Name)
 }
 
+func TestContainerDefinitionsForTypeDetailed(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name: "app-a",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+		Is: NewIs(&mockA{}),
+	})
+	b.Add(&Def{
+		Name:  "request-a",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+		Is: NewIs(&mockA{}),
+	})
+
+	app, _ := b.Build()
+	req, _ := app.SubContainer()
+
+	matches := app.DefinitionsForTypeDetailed(reflect.TypeOf(&mockA{}))
+	require.Equal(t, 2, len(matches))
+	require.Equal(t, "app-a", matches[0].Def.Name)
+	require.Equal(t, App, matches[0].Scope)
+	require.True(t, matches[0].Reachable)
+	require.Equal(t, "request-a", matches[1].Def.Name)
+	require.Equal(t, Request, matches[1].Scope)
+	require.False(t, matches[1].Reachable)
+
+	matches = req.DefinitionsForTypeDetailed(reflect.TypeOf(&mockA{}))
+	require.True(t, matches[0].Reachable)
+	require.True(t, matches[1].Reachable)
+
+	require.Equal(t, 0, len(app.DefinitionsForTypeDetailed(reflect.TypeOf(""))))
+}
+
 func TestContainerScope(t *testing.T) {
 	b, _ := NewEnhancedBuilder()
 	app, _ := b.Build()
@@ -232,6 +307,22 @@ func TestContainerScopes(t *testing.T) {
 	require.Equal(t, list, subrequest.Scopes())
 }
 
+func TestContainerUsedScopes(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+	app, _ := b.Build()
+	require.Empty(t, app.UsedScopes())
+
+	b, _ = NewEnhancedBuilder()
+	b.Add(NewDefFor(1).SetName("o1").SetScope(SubRequest))
+	b.Add(NewDefFor(2).SetName("o2"))
+	b.Add(NewDefFor(3).SetName("o3").SetScope(SubRequest))
+	app, _ = b.Build()
+	request, _ := app.SubContainer()
+
+	require.Equal(t, []string{App, SubRequest}, app.UsedScopes())
+	require.Equal(t, []string{App, SubRequest}, request.UsedScopes())
+}
+
 func TestContainerParentScopes(t *testing.T) {
 	b, _ := NewEnhancedBuilder()
 	app, _ := b.Build()
@@ -253,3 +344,151 @@ func TestContainerSubScopes(t *testing.T) {
 	require.Equal(t, []string{SubRequest}, request.SubScopes())
 	require.Empty(t, subrequest.SubScopes())
 }
+
+func TestContainerClosableDefinitions(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name: "with-close",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, nil
+		},
+		Close: func(obj interface{}) error {
+			return nil
+		},
+	})
+	b.Add(&Def{
+		Name: "without-close",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, nil
+		},
+	})
+	b.Add(&Def{
+		Name: "with-cleanup",
+		BuildWithCleanup: func(ctn Container) (interface{}, func() error, error) {
+			return nil, nil, nil
+		},
+	})
+
+	app, _ := b.Build()
+
+	require.Equal(t, []string{"with-close", "with-cleanup"}, app.ClosableDefinitions())
+}
+
+func TestContainerDefinitionScopeLevel(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	def := &Def{
+		Name:  "o1",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, nil
+		},
+		Is: NewIs(&mockA{}),
+	}
+
+	b.Add(def)
+	b.Add(&Def{
+		Name: "o2",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, nil
+		},
+	})
+
+	app, _ := b.Build()
+
+	for _, in := range []interface{}{"o1", def, *def, def.Index(), reflect.TypeOf(&mockA{})} {
+		level, err := app.DefinitionScopeLevel(in)
+		require.Nil(t, err)
+		require.Equal(t, 1, level)
+	}
+
+	level, err := app.DefinitionScopeLevel("o2")
+	require.Nil(t, err)
+	require.Equal(t, 0, level)
+
+	for _, in := range []interface{}{"o3", 10, reflect.TypeOf(&mockB{}), 1.5} {
+		_, err = app.DefinitionScopeLevel(in)
+		require.NotNil(t, err)
+	}
+
+	level, ok := app.ScopeLevelOf("o1")
+	require.True(t, ok)
+	require.Equal(t, 1, level)
+
+	level, ok = app.ScopeLevelOf("o2")
+	require.True(t, ok)
+	require.Equal(t, 0, level)
+
+	_, ok = app.ScopeLevelOf("o3")
+	require.False(t, ok)
+
+	scope, ok := app.ScopeOf("o1")
+	require.True(t, ok)
+	require.Equal(t, Request, scope)
+
+	scope, ok = app.ScopeOf("o2")
+	require.True(t, ok)
+	require.Equal(t, App, scope)
+
+	_, ok = app.ScopeOf("o3")
+	require.False(t, ok)
+
+	require.Equal(t, map[string]int{App: 0, Request: 1, SubRequest: 2}, app.ScopeLevels())
+}
+
+func TestContainerUnsharedInstances(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name: "unshared",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+		Close: func(obj interface{}) error {
+			return nil
+		},
+		Unshared: true,
+	})
+	b.Add(&Def{
+		Name: "unshared-without-close",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+		Unshared: true,
+	})
+
+	app, _ := b.Build()
+
+	require.Empty(t, app.UnsharedInstances("unshared"))
+	require.Empty(t, app.UnsharedInstances("unknown"))
+
+	obj1 := app.Get("unshared")
+	obj2 := app.Get("unshared")
+	app.Get("unshared-without-close")
+
+	instances := app.UnsharedInstances("unshared")
+	require.Len(t, instances, 2)
+	require.True(t, instances[0] == obj1)
+	require.True(t, instances[1] == obj2)
+	require.Empty(t, app.UnsharedInstances("unshared-without-close"))
+}
+
+func TestContainerWithName(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+	app, _ := b.Build()
+
+	require.Equal(t, "", app.Name())
+
+	_, err := app.SafeGet("unknown")
+	require.Equal(t, "could not get `unknown` because the definition does not exist", err.Error())
+
+	app = app.WithName("tenant-42")
+	require.Equal(t, "tenant-42", app.Name())
+
+	_, err = app.SafeGet("unknown")
+	require.Equal(t, "could not get `unknown` in container `tenant-42` because the definition does not exist", err.Error())
+
+	request, _ := app.SubContainer()
+	require.Equal(t, "", request.Name())
+}
@@ -1,8 +1,11 @@
 package di
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"reflect"
+	"time"
 )
 
 // NewDef creates a new *Def with only the Build function field set.
@@ -46,9 +49,37 @@ func NewDefForType(obj interface{}) *Def {
 type Def struct {
 	// Build is the function that is used to create the object.
 	Build func(ctn Container) (interface{}, error)
+	// BuildWithCleanup can be used instead of Build to create the object.
+	// In addition to the object, it returns a cleanup function that is called when the container is deleted,
+	// like the Close function. This matches the common `func New() (*T, func() error, error)` constructors.
+	// The cleanup function can be nil. If the Close function is also set, it is called before the cleanup function.
+	// A definition can not have both a Build and a BuildWithCleanup function.
+	BuildWithCleanup func(ctn Container) (obj interface{}, cleanup func() error, err error)
+	// BuildForScope contains Build functions that replace the Build function
+	// when the object is retrieved from a Container in a given scope (the keys of the map).
+	// The object is then stored in this Container, so each of these scopes has its own instance.
+	// If there is no function for the scope of the Container, the parent containers are checked,
+	// up to the scope of the definition. Then, the Build (or BuildWithCleanup) function is used
+	// and the object is stored in the Container of the definition scope, as usual.
+	// The scopes of the map can not be more generic than the definition scope.
+	// The Close function is used for all the objects. BuildForScope is only supported by the EnhancedBuilder.
+	BuildForScope map[string]func(ctn Container) (interface{}, error)
+	// Decorators are applied in order on the object returned by the Build function.
+	// Each decorator receives the current object and returns the object that replaces it,
+	// so the result of the last decorator is the object stored in the Container.
+	// The Close function receives this decorated object.
+	// If a decorator returns an error, the object can not be retrieved, as if the Build function had failed.
+	// In this case, the cleanup function returned by BuildWithCleanup is called, but the Close function is not.
+	Decorators []func(ctn Container, obj interface{}) (interface{}, error)
 	// Close is the function that is used to clean the object when the container is deleted.
 	// It can be nil if nothing needs to be done to close the object.
 	Close func(obj interface{}) error
+	// CloseWithContext can be used instead of Close to clean the object.
+	// It receives the context given to DeleteContext or DeleteWithContext,
+	// so that a slow Close function can stop when the deletion is interrupted.
+	// The other deletion methods give it context.Background().
+	// A definition can not have both a Close and a CloseWithContext function.
+	CloseWithContext func(ctx context.Context, obj interface{}) error
 	// Name is the key that is used to retrieve the object from the container.
 	Name string
 	// Scope determines in which container the object is stored.
@@ -68,8 +99,92 @@ type Def struct {
 	// It allows to create a Build function that creates an object whose fields are filled
 	// depending on their type and the types of the definitions in the Container.
 	Is []reflect.Type
-	// Tags are not used inside this library. But they can be useful to sort your definitions.
+	// Tags are not used to build the objects. But they can be useful to sort your definitions.
+	// The definitions having a given tag can be found with the DefinitionsForTag method of the Container.
 	Tags []Tag
+	// Meta can contain any data attached to the definition. It is not used inside this library,
+	// but it is available in the definitions of the Container, so that tools and frameworks
+	// can read it (e.g. the documentation of an http handler).
+	// The EnhancedBuilder copies the map when the definition is added,
+	// but the values themselves are not copied.
+	Meta map[string]interface{}
+	// Profiles restricts the use of the definition to some profiles.
+	// If it is not empty, the definition is only added to the container
+	// if one of its profiles is activated with the WithProfile option of the EnhancedBuilder Build method.
+	// Definitions without profiles are always added to the container.
+	// Note that a definition still replaces the previous definition with the same name
+	// when it is added to the builder, even if their profiles are different.
+	Profiles []string
+	// Validate is an optional function that checks the definition when the container is generated
+	// by the Build method of the EnhancedBuilder. It receives the definition as it will be stored in the container,
+	// with its Scope already set. If it returns an error, the container is not generated.
+	Validate func(def Def) error
+	// Lazy excludes the definition from the eager construction of BuildEagerContext.
+	// The object is only built when it is retrieved for the first time, as with the Build method.
+	// It has no effect on containers generated with the Build method.
+	Lazy bool
+	// Primary marks the definition as the one to use when an object is retrieved by type
+	// and several definitions match this type. It is only used with the Primary TypeResolution
+	// (check the WithTypeResolution option of the EnhancedBuilder Build method).
+	Primary bool
+	// Pooled allows to reuse the objects of a shared definition across containers.
+	// It is meant for objects in a sub-scope (e.g. request) that are expensive to allocate but cheap to reset.
+	// When a Container is deleted, the object is given to the Reset function and put in a pool
+	// instead of being closed. The next Container needing the object takes it from the pool
+	// instead of calling the Build function. The pool is shared by all the containers generated by the same builder.
+	// Pooled objects must not be retained after the deletion of their Container,
+	// and they should not keep references to objects of their Container (the Reset function can clear them).
+	// A pooled definition can not be unshared, can not use RetryInFreshContainer,
+	// and must use a Build function, not BuildWithCleanup.
+	// Its Close function is never called. Pooled definitions are only supported by the EnhancedBuilder.
+	Pooled bool
+	// Reset is called on a pooled object before it is put back in the pool. It can be nil.
+	Reset func(obj interface{})
+	// DependsOn contains the names of the definitions whose objects are used by the Build function.
+	// These objects are retrieved before the Build function is called, so they are closed after the object
+	// of this definition, even if the Build function does not retrieve them (e.g. a metrics buffer that must be flushed
+	// before the http server serving the metrics is stopped). The Build function still needs to retrieve them to use them.
+	// The EnhancedBuilder Build method returns an error if a name is not defined or if it is in a more specific scope.
+	// It is also used to set the scope of the definition with the WithInferScopes option of the EnhancedBuilder Build method.
+	DependsOn []string
+	// CloseBefore contains the names of the definitions whose objects must be closed
+	// after the object of this definition when the Container is deleted.
+	// The order of the Close functions usually follows the dependencies between the objects,
+	// but it can not be deduced if an object does not retrieve the other one from the Container
+	// (e.g. an http server that must be stopped before the database is closed).
+	// The names that do not match any definition are ignored.
+	// Close ordering hints are only supported by the EnhancedBuilder.
+	CloseBefore []string
+	// CloseAfter contains the names of the definitions whose objects must be closed
+	// before the object of this definition when the Container is deleted. It is the opposite of CloseBefore.
+	CloseAfter []string
+	// Retries is the number of times the Build function is called again
+	// when it returns an error wrapping ErrRetryable. The default value 0 disables the retries.
+	// Only the error of the last attempt is returned.
+	Retries int
+	// RetryInFreshContainer makes the Build function use a new Container for each retry
+	// when Retries is used. The first attempt uses the Container storing the object, as usual.
+	// The new Container has the same scope and the same parent as the Container storing the object,
+	// so the objects of this scope retrieved during a failed retry are not kept.
+	// The Container of a failed retry is deleted right away, and its objects are closed.
+	// The Container of the successful retry is deleted when the object is closed.
+	// Be careful: if a retry succeeds, the objects of the same scope retrieved by the Build function
+	// (e.g. the transaction of a request) are not the ones of the Container storing the object,
+	// but copies built in the new Container. The objects of the more generic scopes are shared as usual.
+	// It costs the creation of a Container for each retry.
+	RetryInFreshContainer bool
+	// BuildTimeout is the maximum duration of the build of an object, including its retries
+	// and the retrieval of the dependencies from its Build function. The default value 0 disables the timeout.
+	// The Build function is called in its own goroutine, and the object is not stored in the Container
+	// if it is not built in time. The error returned in this case wraps ErrTimeout.
+	// The goroutine can not be stopped, so the Build function keeps running after the timeout.
+	// If it eventually succeeds, the object is closed right away with the Close function of the definition.
+	BuildTimeout time.Duration
+	// Deprecated can contain a message explaining why the definition should not be used anymore
+	// (e.g. "use `newService` instead"). The first time the object is retrieved from a Container,
+	// the message is given to the warning handlers registered with the OnWarning method of the EnhancedBuilder.
+	// There is at most one warning per definition and per Container.
+	Deprecated string
 
 	// builderBound is set to true when the definition is bound to a Container by the builder.
 	builderBound bool
@@ -87,18 +202,87 @@ func (d *Def) Index() int {
 	return -1
 }
 
+// IsShared returns true if the objects of the definition are shared,
+// meaning that its Unshared field is false.
+func (d Def) IsShared() bool {
+	return !d.Unshared
+}
+
+// BuildIn creates a new object with the Build or BuildWithCleanup function of the definition,
+// using the given Container to retrieve the dependencies. The object is not saved in any Container.
+// It is meant to test the construction of a single object with a Container filled with stubs.
+//
+// Once the definition has been bound to a Container by the EnhancedBuilder,
+// its Build function includes the decorators registered with the Decorate method.
+// The Decorators of the definition are also applied on the object.
+// The Close function is never called on the object.
+// If the definition uses BuildWithCleanup, the cleanup function is ignored.
+func (d *Def) BuildIn(ctn Container) (obj interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("could not build `%s` because the build function panicked: %+v", d.Name, r)
+		}
+	}()
+
+	switch {
+	case d.BuildWithCleanup != nil:
+		obj, _, err = d.BuildWithCleanup(ctn)
+	case d.Build != nil:
+		obj, err = d.Build(ctn)
+	default:
+		return nil, fmt.Errorf("could not build `%s` because the definition does not have a Build function", d.Name)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not build `%s`: %w", d.Name, err)
+	}
+
+	return d.decorate(ctn, obj)
+}
+
+// decorate applies the Decorators of the definition on the given object.
+func (d *Def) decorate(ctn Container, obj interface{}) (interface{}, error) {
+	for _, decorator := range d.Decorators {
+		decorated, err := decorator(ctn, obj)
+		if err != nil {
+			return nil, fmt.Errorf("could not decorate `%s`: %w", d.Name, err)
+		}
+		obj = decorated
+	}
+
+	return obj, nil
+}
+
 // SetBuild is the setter for the Build field.
 func (d *Def) SetBuild(build func(ctn Container) (interface{}, error)) *Def {
 	d.Build = build
 	return d
 }
 
+// SetBuildWithCleanup is the setter for the BuildWithCleanup field.
+func (d *Def) SetBuildWithCleanup(build func(ctn Container) (interface{}, func() error, error)) *Def {
+	d.BuildWithCleanup = build
+	return d
+}
+
+// AddDecorator appends a function to the Decorators field.
+func (d *Def) AddDecorator(decorator func(ctn Container, obj interface{}) (interface{}, error)) *Def {
+	d.Decorators = append(d.Decorators, decorator)
+	return d
+}
+
 // SetClose is the setter for the Close field.
 func (d *Def) SetClose(close func(obj interface{}) error) *Def {
 	d.Close = close
 	return d
 }
 
+// SetCloseWithContext is the setter for the CloseWithContext field.
+func (d *Def) SetCloseWithContext(close func(ctx context.Context, obj interface{}) error) *Def {
+	d.CloseWithContext = close
+	return d
+}
+
 // SetName is the setter for the Name field.
 func (d *Def) SetName(name string) *Def {
 	d.Name = name
@@ -131,13 +315,221 @@ func (d *Def) SetTags(tags ...Tag) *Def {
 	return d
 }
 
+// SetMeta is the setter for the Meta field.
+func (d *Def) SetMeta(meta map[string]interface{}) *Def {
+	d.Meta = meta
+	return d
+}
+
+// SetProfiles is the setter for the Profiles field.
+func (d *Def) SetProfiles(profiles ...string) *Def {
+	d.Profiles = profiles
+	return d
+}
+
+// SetValidate is the setter for the Validate field.
+func (d *Def) SetValidate(validate func(def Def) error) *Def {
+	d.Validate = validate
+	return d
+}
+
+// SetLazy is the setter for the Lazy field.
+func (d *Def) SetLazy(lazy bool) *Def {
+	d.Lazy = lazy
+	return d
+}
+
+// SetPooled is the setter for the Pooled field.
+func (d *Def) SetPooled(pooled bool) *Def {
+	d.Pooled = pooled
+	return d
+}
+
+// SetReset is the setter for the Reset field.
+func (d *Def) SetReset(reset func(obj interface{})) *Def {
+	d.Reset = reset
+	return d
+}
+
+// SetDependsOn is the setter for the DependsOn field.
+func (d *Def) SetDependsOn(names ...string) *Def {
+	d.DependsOn = names
+	return d
+}
+
+// SetBuildForScope is the setter for the BuildForScope field.
+func (d *Def) SetBuildForScope(builds map[string]func(ctn Container) (interface{}, error)) *Def {
+	d.BuildForScope = builds
+	return d
+}
+
+// SetCloseBefore is the setter for the CloseBefore field.
+func (d *Def) SetCloseBefore(names ...string) *Def {
+	d.CloseBefore = names
+	return d
+}
+
+// SetCloseAfter is the setter for the CloseAfter field.
+func (d *Def) SetCloseAfter(names ...string) *Def {
+	d.CloseAfter = names
+	return d
+}
+
+// SetPrimary is the setter for the Primary field.
+func (d *Def) SetPrimary(primary bool) *Def {
+	d.Primary = primary
+	return d
+}
+
+// SetRetries is the setter for the Retries field.
+func (d *Def) SetRetries(retries int) *Def {
+	d.Retries = retries
+	return d
+}
+
+// SetRetryInFreshContainer is the setter for the RetryInFreshContainer field.
+func (d *Def) SetRetryInFreshContainer(fresh bool) *Def {
+	d.RetryInFreshContainer = fresh
+	return d
+}
+
+// SetBuildTimeout is the setter for the BuildTimeout field.
+func (d *Def) SetBuildTimeout(timeout time.Duration) *Def {
+	d.BuildTimeout = timeout
+	return d
+}
+
+// SetDeprecated is the setter for the Deprecated field.
+func (d *Def) SetDeprecated(message string) *Def {
+	d.Deprecated = message
+	return d
+}
+
+// isInProfiles returns true if the definition should be used with the given active profiles.
+func (d *Def) isInProfiles(activeProfiles []string) bool {
+	if len(d.Profiles) == 0 {
+		return true
+	}
+
+	for _, profile := range d.Profiles {
+		for _, activeProfile := range activeProfiles {
+			if profile == activeProfile {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// checkBuildFunctions checks that the definition has exactly one build function.
+func (d *Def) checkBuildFunctions() error {
+	if d.Build == nil && d.BuildWithCleanup == nil {
+		return errors.New("the Build function can not be nil")
+	}
+	if d.Build != nil && d.BuildWithCleanup != nil {
+		return errors.New("the definition can not have both a Build and a BuildWithCleanup function")
+	}
+	if d.Close != nil && d.CloseWithContext != nil {
+		return errors.New("the definition can not have both a Close and a CloseWithContext function")
+	}
+	return nil
+}
+
+// checkPooled checks that the definition can be pooled if its Pooled field is true.
+func (d *Def) checkPooled() error {
+	if !d.Pooled {
+		return nil
+	}
+	if d.Unshared {
+		return errors.New("a pooled definition can not be unshared")
+	}
+	if d.BuildWithCleanup != nil {
+		return errors.New("a pooled definition can not use a BuildWithCleanup function")
+	}
+	if len(d.BuildForScope) > 0 {
+		return errors.New("a pooled definition can not use BuildForScope functions")
+	}
+	if d.RetryInFreshContainer {
+		return errors.New("a pooled definition can not use the RetryInFreshContainer option")
+	}
+	return nil
+}
+
+// checkBuildForScope checks that the scopes of the BuildForScope field are available
+// and that they are not more generic than the definition scope.
+func (d *Def) checkBuildForScope(scopes ScopeList) error {
+	level := 0
+	for i, s := range scopes {
+		if s == d.Scope {
+			level = i
+		}
+	}
+
+	for scope, build := range d.BuildForScope {
+		if build == nil {
+			return fmt.Errorf("the BuildForScope function for scope `%s` can not be nil", scope)
+		}
+		if !scopes.Contains(scope) {
+			return fmt.Errorf("the BuildForScope field uses scope `%s` which is not allowed", scope)
+		}
+		if ScopeList(scopes[:level]).Contains(scope) {
+			return fmt.Errorf("the BuildForScope field uses scope `%s` which is more generic than the definition scope `%s`", scope, d.Scope)
+		}
+	}
+
+	return nil
+}
+
+// closeFunc returns the function that should be used to close an object built from this definition.
+// cleanup is the cleanup function returned by BuildWithCleanup. It can be nil.
+// If the object does not need to be closed, the returned function is nil.
+func (d *Def) closeFunc(cleanup func() error) func(obj interface{}) error {
+	return d.closeFuncWithContext(context.Background(), cleanup)
+}
+
+// closeFuncWithContext works like closeFunc, but the given context is used to call the CloseWithContext function.
+func (d *Def) closeFuncWithContext(ctx context.Context, cleanup func() error) func(obj interface{}) error {
+	closeFunc := d.Close
+
+	if d.CloseWithContext != nil {
+		closeWithContext := d.CloseWithContext
+		closeFunc = func(obj interface{}) error {
+			return closeWithContext(ctx, obj)
+		}
+	}
+
+	if cleanup == nil {
+		return closeFunc
+	}
+
+	return func(obj interface{}) error {
+		if closeFunc != nil {
+			if err := closeFunc(obj); err != nil {
+				return err
+			}
+		}
+		return cleanup()
+	}
+}
+
 // NewIs applies reflect.TypeOf to all the given instances
 // and returns a slice of []reflect.Type.
 // It can be used to fill the Def.Is field.
+//
+// The instances that already are a reflect.Type are kept as they are.
+// It allows to declare an interface type, which can not be obtained from an instance:
+//
+//	di.NewIs(&MyObject{}, reflect.TypeOf((*MyInterface)(nil)).Elem())
+//	di.NewIs(&MyObject{}, di.IsInterface[MyInterface]()) // with go1.18 or later
 func NewIs(instances ...interface{}) []reflect.Type {
 	is := []reflect.Type{}
 
 	for _, instance := range instances {
+		if typ, ok := instance.(reflect.Type); ok {
+			is = append(is, typ)
+			continue
+		}
 		is = append(is, reflect.TypeOf(instance))
 	}
 
@@ -0,0 +1,46 @@
+//go:build go1.18
+// +build go1.18
+
+package di
+
+import "reflect"
+
+// TypedDef is a definition whose Build function returns a T.
+// It embeds a *Def, so it can be added to an EnhancedBuilder like any other definition:
+//
+//	def := di.NewTypedDef(func(ctn di.Container) (*MyObject, error) { return &MyObject{}, nil })
+//	builder.Add(def.Def)
+//
+// Once the container is built, the Get method can be used to retrieve the object without casting it.
+type TypedDef[T any] struct {
+	*Def
+}
+
+// NewTypedDef creates a new *TypedDef with only the Build function field set.
+// The other fields can be set with the setters of the embedded *Def.
+func NewTypedDef[T any](build func(ctn Container) (T, error)) *TypedDef[T] {
+	return &TypedDef[T]{
+		Def: NewDef(func(ctn Container) (interface{}, error) {
+			return build(ctn)
+		}),
+	}
+}
+
+// Get retrieves the object of the definition from the given Container, like SafeGet does.
+// It returns an error if the object can not be retrieved
+// or if its Build function has been replaced by one that does not return a T.
+// It is the same as GetTyped[T](ctn, d.Def).
+func (d *TypedDef[T]) Get(ctn Container) (T, error) {
+	return GetTyped[T](ctn, d.Def)
+}
+
+// IsInterface returns the reflect.Type of T. It is meant to be used with an interface type,
+// whose reflect.Type can not be obtained from an instance.
+// The result can be given to NewIs and SetIs:
+//
+//	def.SetIs(&MyWriter{}, di.IsInterface[io.Writer]())
+//
+// It is equivalent to reflect.TypeOf((*T)(nil)).Elem(), which is often mistaken for reflect.TypeOf((*T)(nil)).
+func IsInterface[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
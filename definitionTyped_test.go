@@ -0,0 +1,85 @@
+//go:build go1.18
+// +build go1.18
+
+package di
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedDef(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	defA := NewTypedDef(func(ctn Container) (*mockA, error) {
+		return &mockA{SField: "a"}, nil
+	})
+	defA.SetName("a")
+	require.Nil(t, b.Add(defA.Def))
+
+	defHandler := NewTypedDef(func(ctn Container) (mockHandler, error) {
+		return &mockHandlerImpl{name: "handler"}, nil
+	})
+	require.Nil(t, b.Add(defHandler.Def))
+
+	defNil := NewTypedDef(func(ctn Container) (*mockA, error) {
+		return nil, nil
+	})
+	require.Nil(t, b.Add(defNil.Def))
+
+	defErr := NewTypedDef(func(ctn Container) (*mockA, error) {
+		return nil, errors.New("build error")
+	})
+	require.Nil(t, b.Add(defErr.Def))
+
+	defWrongType := NewTypedDef(func(ctn Container) (*mockA, error) {
+		return nil, nil
+	})
+	defWrongType.SetBuild(func(ctn Container) (interface{}, error) {
+		return "not a *mockA", nil
+	})
+	require.Nil(t, b.Add(defWrongType.Def))
+
+	app, _ := b.Build()
+
+	a, err := defA.Get(app)
+	require.Nil(t, err)
+	require.Equal(t, "a", a.SField)
+	require.True(t, a == app.Get("a"))
+
+	handler, err := defHandler.Get(app)
+	require.Nil(t, err)
+	require.Equal(t, "handler", handler.Handle())
+
+	a, err = defNil.Get(app)
+	require.Nil(t, err)
+	require.Nil(t, a)
+
+	_, err = defErr.Get(app)
+	require.NotNil(t, err)
+
+	_, err = defWrongType.Get(app.WithName("main"))
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), " in container `main` because the object is a `string` and not a `*di.mockA`")
+}
+
+func TestIsInterface(t *testing.T) {
+	handlerType := reflect.TypeOf((*mockHandler)(nil)).Elem()
+
+	require.Equal(t, handlerType, IsInterface[mockHandler]())
+	require.Equal(t, reflect.TypeOf(&mockA{}), IsInterface[*mockA]())
+
+	b, _ := NewEnhancedBuilder()
+	b.Add(NewDef(func(ctn Container) (interface{}, error) {
+		return &mockHandlerImpl{name: "h"}, nil
+	}).SetIs(IsInterface[mockHandler]()))
+
+	app, _ := b.Build()
+
+	h, err := app.SafeGet(handlerType)
+	require.Nil(t, err)
+	require.Equal(t, "h", h.(*mockHandlerImpl).name)
+}
@@ -1,6 +1,7 @@
 package di
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 
@@ -58,21 +59,163 @@ func TestDefConstructors(t *testing.T) {
 	require.NotNil(t, err, "NewDefForType only works for structs and pointers to structs")
 }
 
+func TestNewIs(t *testing.T) {
+	handlerType := reflect.TypeOf((*mockHandler)(nil)).Elem()
+
+	require.Equal(t, []reflect.Type{}, NewIs())
+	require.Equal(t, []reflect.Type{reflect.TypeOf(""), reflect.TypeOf(&mockA{})}, NewIs("", &mockA{}))
+	require.Equal(t, []reflect.Type{reflect.TypeOf(&mockA{}), handlerType}, NewIs(&mockA{}, handlerType))
+}
+
 func TestDefSetters(t *testing.T) {
 	def := NewDef(nil).
 		SetBuild(func(ctn Container) (interface{}, error) { return nil, nil }).
+		SetBuildWithCleanup(func(ctn Container) (interface{}, func() error, error) { return nil, nil, nil }).
 		SetClose(func(obj interface{}) error { return nil }).
 		SetName("name").
 		SetScope(App).
 		SetUnshared(true).
 		SetIs("", Def{}, &Def{}).
-		SetTags(Tag{Name: "tag1"}, Tag{Name: "tag2"})
+		SetTags(Tag{Name: "tag1"}, Tag{Name: "tag2"}).
+		SetMeta(map[string]interface{}{"key": 1}).
+		SetProfiles("test", "prod").
+		SetValidate(func(def Def) error { return nil }).
+		SetLazy(true).
+		SetPrimary(true).
+		SetPooled(true).
+		SetReset(func(obj interface{}) {}).
+		SetDependsOn("dependency").
+		SetBuildForScope(map[string]func(ctn Container) (interface{}, error){Request: nil}).
+		SetCloseBefore("before").
+		SetCloseAfter("after")
 
 	require.NotNil(t, def.Build)
+	require.NotNil(t, def.BuildWithCleanup)
 	require.NotNil(t, def.Close)
 	require.Equal(t, "name", def.Name)
 	require.Equal(t, App, def.Scope)
 	require.Equal(t, true, def.Unshared)
+	require.False(t, def.IsShared())
+	require.True(t, NewDef(nil).IsShared())
 	require.Equal(t, []reflect.Type{reflect.TypeOf(""), reflect.TypeOf(Def{}), reflect.TypeOf(&Def{})}, def.Is)
 	require.Equal(t, []Tag{{Name: "tag1"}, {Name: "tag2"}}, def.Tags)
+	require.Equal(t, map[string]interface{}{"key": 1}, def.Meta)
+	require.Equal(t, []string{"test", "prod"}, def.Profiles)
+	require.NotNil(t, def.Validate)
+	require.Equal(t, true, def.Lazy)
+	require.Equal(t, true, def.Primary)
+	require.Equal(t, true, def.Pooled)
+	require.NotNil(t, def.Reset)
+	require.Equal(t, []string{"dependency"}, def.DependsOn)
+	require.Len(t, def.BuildForScope, 1)
+	require.Equal(t, []string{"before"}, def.CloseBefore)
+	require.Equal(t, []string{"after"}, def.CloseAfter)
+}
+
+func TestDefBuildIn(t *testing.T) {
+	stubBuilder, _ := NewEnhancedBuilder()
+	stubBuilder.Add(NewDefFor("stub").SetName("dependency"))
+	stubs, _ := stubBuilder.Build()
+
+	def := NewDef(func(ctn Container) (interface{}, error) {
+		return ctn.Get("dependency").(string) + "-object", nil
+	}).SetName("object")
+
+	obj, err := def.BuildIn(stubs)
+	require.Nil(t, err)
+	require.Equal(t, "stub-object", obj)
+
+	obj2, _ := def.BuildIn(stubs)
+	require.Equal(t, obj, obj2)
+	require.False(t, stubs.NameIsDefined("object"))
+
+	// Decorators are included once the definition is bound.
+	b, _ := NewEnhancedBuilder()
+	b.Add(def)
+	b.Decorate("object", func(prev interface{}, ctn Container) (interface{}, error) {
+		return prev.(string) + "-decorated", nil
+	})
+	b.Build()
+
+	obj, err = def.BuildIn(stubs)
+	require.Nil(t, err)
+	require.Equal(t, "stub-object-decorated", obj)
+
+	// Errors and panics.
+	_, err = NewDef(func(ctn Container) (interface{}, error) {
+		return ctn.Get("unknown"), nil
+	}).BuildIn(stubs)
+	require.NotNil(t, err)
+
+	_, err = NewDef(nil).BuildIn(stubs)
+	require.NotNil(t, err)
+
+	obj, err = NewDef(nil).SetBuildWithCleanup(func(ctn Container) (interface{}, func() error, error) {
+		return "cleanup", func() error { return nil }, nil
+	}).BuildIn(stubs)
+	require.Nil(t, err)
+	require.Equal(t, "cleanup", obj)
+}
+
+func TestDefDecorators(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	closed := []interface{}{}
+	cleaned := 0
+
+	def := NewDef(func(ctn Container) (interface{}, error) {
+		return "object", nil
+	}).SetName("object").SetClose(func(obj interface{}) error {
+		closed = append(closed, obj)
+		return nil
+	}).AddDecorator(func(ctn Container, obj interface{}) (interface{}, error) {
+		return obj.(string) + " decorated by " + ctn.Get("decorator").(string), nil
+	}).AddDecorator(func(ctn Container, obj interface{}) (interface{}, error) {
+		return "[" + obj.(string) + "]", nil
+	})
+	require.Nil(t, b.Add(def))
+
+	require.Nil(t, b.Add(&Def{
+		Name: "decorator",
+		Build: func(ctn Container) (interface{}, error) {
+			return "d1", nil
+		},
+	}))
+
+	require.Nil(t, b.Add(&Def{
+		Name: "failing",
+		BuildWithCleanup: func(ctn Container) (interface{}, func() error, error) {
+			return "object", func() error {
+				cleaned++
+				return nil
+			}, nil
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, obj)
+			return nil
+		},
+		Decorators: []func(ctn Container, obj interface{}) (interface{}, error){
+			func(ctn Container, obj interface{}) (interface{}, error) {
+				return nil, errors.New("decorator error")
+			},
+		},
+	}))
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	require.Equal(t, "[object decorated by d1]", app.Get(def))
+	require.Equal(t, "[object decorated by d1]", app.Get("object"))
+
+	_, err = app.SafeGet("failing")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "could not decorate `failing`: decorator error")
+	require.Equal(t, 1, cleaned)
+
+	obj, err := def.BuildIn(app)
+	require.Nil(t, err)
+	require.Equal(t, "[object decorated by d1]", obj)
+
+	require.Nil(t, app.Delete())
+	require.Equal(t, []interface{}{"[object decorated by d1]"}, closed)
 }
@@ -0,0 +1,22 @@
+package di
+
+import "fmt"
+
+// warnDeprecated gives the Deprecated message of a definition to the warning handlers,
+// unless it has already been done for this container.
+func (core *containerCore) warnDeprecated(index int) {
+	core.m.Lock()
+	if _, ok := core.deprecationWarned[index]; ok {
+		core.m.Unlock()
+		return
+	}
+	if core.deprecationWarned == nil {
+		core.deprecationWarned = map[int]struct{}{}
+	}
+	core.deprecationWarned[index] = struct{}{}
+	core.m.Unlock()
+
+	def := core.definitions[index]
+
+	core.config.warn(def, fmt.Errorf("`%s` is deprecated%s: %s", def.Name, core.nameSuffix(), def.Deprecated))
+}
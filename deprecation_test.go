@@ -0,0 +1,59 @@
+package di
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeprecatedDefinition(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	warnings := []string{}
+	b.OnWarning(func(def Def, err error) {
+		warnings = append(warnings, err.Error())
+	})
+
+	require.Nil(t, b.Add(&Def{
+		Name:       "old",
+		Scope:      Request,
+		Unshared:   true,
+		Deprecated: "use `new` instead",
+		Build:      func(ctn Container) (interface{}, error) { return 1, nil },
+	}))
+	require.Nil(t, b.Add(&Def{
+		Name:  "new",
+		Build: func(ctn Container) (interface{}, error) { return 2, nil },
+	}))
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	app.Get("new")
+	require.Empty(t, warnings)
+
+	_, err = app.SafeGet("old")
+	require.NotNil(t, err, "old is not in the app scope")
+	require.Empty(t, warnings, "there should be no warning if the object can not be retrieved from the container")
+
+	req1, _ := app.SubContainer()
+	req1.Get("old")
+	req1.Get("old")
+	require.Equal(t, []string{"`old` is deprecated: use `new` instead"}, warnings)
+
+	req2, _ := app.WithName("app").SubContainer()
+	req2.Get("old")
+	require.Len(t, warnings, 2, "there should be one warning per container")
+
+	// Without warning handler, the definition can still be used.
+	b, _ = NewEnhancedBuilder()
+	require.Nil(t, b.Add(&Def{
+		Name:       "old",
+		Deprecated: "use `new` instead",
+		Build:      func(ctn Container) (interface{}, error) { return 1, nil },
+	}))
+
+	app, err = b.Build()
+	require.Nil(t, err)
+	require.Equal(t, 1, app.Get("old"))
+}
@@ -0,0 +1,89 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDegraded can be returned by a Build function, along with a non-nil object,
+// to indicate that the object was created but that it works in a degraded mode
+// (e.g. because an optional dependency is not available).
+// The error can be wrapped to give more details about the problem.
+//
+// In this case, the container does not consider it as a failure.
+// The object is saved in the container as if no error was returned,
+// and the error is given to the warning handlers registered with the OnWarning method of the EnhancedBuilder.
+//
+// If the object is nil, the error is considered as a normal build error.
+var ErrDegraded = errors.New("the object works in degraded mode")
+
+// ErrContainerClosed is wrapped in the errors returned when an object is requested
+// from a Container that has been deleted, or from a Container deleted while the object was being built.
+// It can be detected with errors.Is, for example to answer with a 503 status code
+// when an http request is processed while the application is shutting down.
+var ErrContainerClosed = errors.New("the container has been deleted")
+
+// ErrRetryable can be returned by a Build function to indicate that the error is temporary.
+// If the Retries field of the definition is set, the Build function is called again.
+// The error can be wrapped to give more details about the problem.
+var ErrRetryable = errors.New("the error is temporary and the build can be retried")
+
+// ErrNotDefined is wrapped in the errors returned when an object is requested
+// with a name, a type or an index that does not match any definition.
+var ErrNotDefined = errors.New("the definition does not exist")
+
+// ErrScopeMismatch is wrapped in the errors returned when an object is requested from a Container
+// that can not reach the scope of its definition (the scope of the Container is more generic).
+var ErrScopeMismatch = errors.New("this scope does not match the container scope or any of its parents scope")
+
+// ErrCycle is wrapped in the errors returned when an object depends on itself,
+// directly or through other objects.
+var ErrCycle = errors.New("there is a cycle in the object definitions")
+
+// ErrTimeout is wrapped in the errors returned by SafeGetWithTimeout
+// when the object is not retrieved before the end of the timeout,
+// and in the errors returned when the build of an object exceeds the BuildTimeout of its definition.
+var ErrTimeout = errors.New("the object was not retrieved in time")
+
+// ErrBuildFailed matches the errors returned when the Build function of a definition fails or panics,
+// including the errors of its Decorators and of the object wrapper.
+// The error returned by the Build function can still be retrieved with errors.Is and errors.As.
+var ErrBuildFailed = errors.New("the object could not be built")
+
+// buildError is the error returned when an object could not be built.
+// Its message is the message of the wrapped error, but it also matches ErrBuildFailed.
+type buildError struct {
+	err error
+}
+
+func (e *buildError) Error() string {
+	return e.err.Error()
+}
+
+func (e *buildError) Unwrap() error {
+	return e.err
+}
+
+func (e *buildError) Is(target error) bool {
+	return target == ErrBuildFailed
+}
+
+// sentinelError is an error with its own message that matches one of the sentinel errors with errors.Is.
+// It allows to keep the messages of the errors while wrapping the sentinel errors.
+type sentinelError struct {
+	msg      string
+	sentinel error
+}
+
+// newSentinelError creates an error with the given message, that matches the sentinel error.
+func newSentinelError(sentinel error, format string, args ...interface{}) error {
+	return &sentinelError{msg: fmt.Sprintf(format, args...), sentinel: sentinel}
+}
+
+func (e *sentinelError) Error() string {
+	return e.msg
+}
+
+func (e *sentinelError) Is(target error) bool {
+	return target == e.sentinel
+}
@@ -0,0 +1,248 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrDegraded(t *testing.T) {
+	var numBuild int
+	warnings := []string{}
+
+	b, _ := NewEnhancedBuilder()
+
+	b.OnWarning(func(def Def, err error) {
+		require.True(t, errors.Is(err, ErrDegraded))
+		warnings = append(warnings, def.Name)
+	})
+	b.OnWarning(func(def Def, err error) {
+		panic("panics in warning handlers are ignored")
+	})
+
+	b.Add(&Def{
+		Name: "degraded",
+		Build: func(ctn Container) (interface{}, error) {
+			numBuild++
+			return "degraded", fmt.Errorf("cache unavailable: %w", ErrDegraded)
+		},
+	})
+	b.Add(&Def{
+		Name:     "degraded-unshared",
+		Unshared: true,
+		BuildWithCleanup: func(ctn Container) (interface{}, func() error, error) {
+			return "degraded-unshared", nil, ErrDegraded
+		},
+	})
+	b.Add(&Def{
+		Name: "degraded-nil",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, ErrDegraded
+		},
+	})
+
+	app, _ := b.Build()
+
+	obj, err := app.SafeGet("degraded")
+	require.Nil(t, err)
+	require.Equal(t, "degraded", obj)
+	require.Equal(t, "degraded", app.Get("degraded"))
+	require.Equal(t, 1, numBuild, "the degraded object should be saved in the container")
+
+	obj, err = app.SafeGet("degraded-unshared")
+	require.Nil(t, err)
+	require.Equal(t, "degraded-unshared", obj)
+
+	_, err = app.SafeGet("degraded-nil")
+	require.NotNil(t, err)
+
+	require.Equal(t, []string{"degraded", "degraded-unshared"}, warnings)
+}
+
+func TestErrContainerClosed(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	b.Add(&Def{
+		Name: "obj",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+	})
+	b.Add(&Def{
+		Name: "slow",
+		Build: func(ctn Container) (interface{}, error) {
+			close(started)
+			<-release
+			return &mockA{}, nil
+		},
+	})
+
+	app, _ := b.Build()
+
+	done := make(chan error)
+	go func() {
+		_, err := app.SafeGet("slow")
+		done <- err
+	}()
+
+	<-started
+	require.Nil(t, app.Delete())
+	close(release)
+
+	err := <-done
+	require.True(t, errors.Is(err, ErrContainerClosed), "deleted during the build")
+
+	_, err = app.SafeGet("obj")
+	require.True(t, errors.Is(err, ErrContainerClosed))
+	require.Equal(t, "could not get `obj` because the container has been deleted", err.Error())
+
+	_, err = app.GetOrStore("key", func() (interface{}, error) { return nil, nil }, nil)
+	require.True(t, errors.Is(err, ErrContainerClosed))
+
+	_, err = app.SubContainer()
+	require.True(t, errors.Is(err, ErrContainerClosed))
+
+	_, err = app.UnscopedSafeGet("obj")
+	require.True(t, errors.Is(err, ErrContainerClosed))
+
+	err = app.ReplaceObject("obj", &mockA{})
+	require.True(t, errors.Is(err, ErrContainerClosed))
+
+	_, err = app.SafeGet("undefined")
+	require.False(t, errors.Is(err, ErrContainerClosed))
+}
+
+func TestTypedGetErrors(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name: "cycle-1",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.SafeGet("cycle-2")
+		},
+	})
+	b.Add(&Def{
+		Name: "cycle-2",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.SafeGet("cycle-1")
+		},
+	})
+	b.Add(&Def{
+		Name: "missing-dependency",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.SafeGet("undefined")
+		},
+	})
+	b.Add(&Def{
+		Name:  "request",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, nil
+		},
+	})
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	_, err = app.SafeGet("undefined")
+	require.True(t, errors.Is(err, ErrNotDefined))
+	require.Equal(t, "could not get `undefined` because the definition does not exist", err.Error())
+
+	_, err = app.SafeGet(reflect.TypeOf(&mockA{}))
+	require.True(t, errors.Is(err, ErrNotDefined))
+	require.Equal(t, "could not get type `*di.mockA` because it is not defined", err.Error())
+
+	_, err = app.SafeGet(100)
+	require.True(t, errors.Is(err, ErrNotDefined))
+	require.Equal(t, "could not get index `100` because it does not exist", err.Error())
+
+	_, err = app.UnscopedSafeGet(reflect.TypeOf(&mockA{}))
+	require.True(t, errors.Is(err, ErrNotDefined))
+	require.Equal(t, "could not get type `*di.mockA` because it is not defined", err.Error())
+
+	_, err = app.UnscopedSafeGet(100)
+	require.True(t, errors.Is(err, ErrNotDefined))
+	require.Equal(t, "could not get index `100` because it does not exist", err.Error())
+
+	_, err = app.SafeGet("missing-dependency")
+	require.True(t, errors.Is(err, ErrNotDefined), "the error of a dependency should be detected")
+
+	_, err = app.SafeGet("request")
+	require.True(t, errors.Is(err, ErrScopeMismatch))
+	require.False(t, errors.Is(err, ErrNotDefined))
+	require.Equal(
+		t,
+		"could not get `request` because it requires `request` scope which does not match this container scope or any of its parents scope",
+		err.Error(),
+	)
+
+	_, err = app.SafeGet("cycle-1")
+	require.True(t, errors.Is(err, ErrCycle))
+	require.Contains(t, err.Error(), "there is a cycle in the object definitions ([cycle-1 cycle-2 cycle-1])")
+
+	// Get panics with the same errors.
+	require.PanicsWithError(t, "could not get `undefined` because the definition does not exist", func() {
+		app.Get("undefined")
+	})
+
+	app.Delete()
+
+	_, err = app.SafeGet("cycle-2")
+	require.True(t, errors.Is(err, ErrContainerClosed))
+	require.Equal(t, "could not get `cycle-2` because the container has been deleted", err.Error())
+
+	_, err = app.SubContainer()
+	require.True(t, errors.Is(err, ErrContainerClosed))
+	require.Equal(t, "the container is closed", err.Error())
+}
+
+func TestErrBuildFailed(t *testing.T) {
+	errCause := errors.New("cause")
+
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name: "failing",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, errCause
+		},
+	})
+	b.Add(&Def{
+		Name: "panicking",
+		Build: func(ctn Container) (interface{}, error) {
+			panic("panic")
+		},
+	})
+	b.Add(&Def{
+		Name: "dependent",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.SafeGet("failing")
+		},
+	})
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	_, err = app.SafeGet("failing")
+	require.True(t, errors.Is(err, ErrBuildFailed))
+	require.True(t, errors.Is(err, errCause))
+	require.Equal(t, "could not build `failing`: cause", err.Error())
+
+	_, err = app.SafeGet("panicking")
+	require.True(t, errors.Is(err, ErrBuildFailed))
+	require.Equal(t, "could not build `panicking` because the build function panicked: panic", err.Error())
+
+	_, err = app.SafeGet("dependent")
+	require.True(t, errors.Is(err, ErrBuildFailed))
+	require.True(t, errors.Is(err, errCause))
+
+	_, err = app.SafeGet("undefined")
+	require.False(t, errors.Is(err, ErrBuildFailed))
+	require.True(t, errors.Is(err, ErrNotDefined))
+}
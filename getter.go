@@ -0,0 +1,23 @@
+package di
+
+import "reflect"
+
+// Getter contains the methods of a Container that retrieve objects
+// and check what is defined, without the methods that manage its lifecycle
+// like Delete or SubContainer.
+// It can be used as a parameter type for the code that should only retrieve objects,
+// like the request handlers, so that it can not delete the container by mistake.
+//
+// Unlike the Context interface of the first version of this package,
+// Getter is not the main type of the package. Containers are still created and managed
+// with the Container type, and a Container can be given to any function expecting a Getter.
+type Getter interface {
+	Get(in interface{}) interface{}
+	SafeGet(in interface{}) (interface{}, error)
+	Fill(in interface{}, dst interface{}) error
+	NameIsDefined(name string) bool
+	TypeIsDefined(typ reflect.Type) bool
+	DefinitionsForType(typ reflect.Type) []Def
+}
+
+var _ Getter = Container{}
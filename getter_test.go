@@ -0,0 +1,34 @@
+package di
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetter(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	require.Nil(t, b.Add(&Def{
+		Name:  "object",
+		Is:    NewIs((*int)(nil)),
+		Build: func(ctn Container) (interface{}, error) { return 1, nil },
+	}))
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	handle := func(g Getter) int {
+		var i int
+		require.Nil(t, g.Fill("object", &i))
+		return i + g.Get("object").(int)
+	}
+
+	require.Equal(t, 2, handle(app))
+
+	var g Getter = app
+	require.True(t, g.NameIsDefined("object"))
+	require.True(t, g.TypeIsDefined(reflect.TypeOf((*int)(nil))))
+	require.Len(t, g.DefinitionsForType(reflect.TypeOf((*int)(nil))), 1)
+}
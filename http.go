@@ -2,6 +2,7 @@ package di
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 )
 
@@ -20,52 +21,153 @@ type ContainerKey string
 //
 // It uses logFunc, a function that can log an error.
 // logFunc is used to log the errors during the container deletion.
+// It can be nil if a Logger was registered with the SetLogger method of the EnhancedBuilder,
+// as this Logger already receives the errors of the Close functions.
+//
+// The request container holds the request context (check WithContext),
+// so the Build functions can retrieve it with the Context method.
+//
+// The request container is deleted when the handler returns.
+// Goroutines started by the handler can use the Done method of the container
+// to know when it happens.
+//
+// HTTPMiddleware is the same as HTTPMiddlewareWithConfig with only the LogFunc field of the configuration.
 func HTTPMiddleware(h http.HandlerFunc, app Container, logFunc func(msg string)) http.HandlerFunc {
+	return HTTPMiddlewareWithConfig(h, app, HTTPMiddlewareConfig{LogFunc: logFunc})
+}
+
+// HTTPHandlerMiddleware returns a middleware with the func(http.Handler) http.Handler signature
+// used by most routers. The middleware works like HTTPMiddleware:
+// each request gets a new sub-container of the app container, deleted when the next handler returns.
+func HTTPHandlerMiddleware(app Container, logFunc func(msg string)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return HTTPMiddleware(next.ServeHTTP, app, logFunc)
+	}
+}
+
+// HTTPMiddlewareConfig is the configuration of HTTPMiddlewareWithConfig.
+type HTTPMiddlewareConfig struct {
+	// Key is the key used to store the container in the request context.
+	// The default value is ContainerKey("di"), the key used by the C function.
+	Key ContainerKey
+	// Scope is the scope of the container injected in the request.
+	// It must be more specific than the scope of the app container.
+	// The default value is the scope right after the scope of the app container.
+	Scope string
+	// LogFunc is used to log the errors during the container deletion. It can be nil.
+	LogFunc func(msg string)
+}
+
+// HTTPMiddlewareWithConfig works like HTTPMiddleware, but the key and the scope
+// of the request container can be configured.
+//
+// If the scope is not right after the scope of the app container,
+// a sub-container is created for each scope in between, and the request container
+// is created from the last one. They are all deleted when the handler returns,
+// the most specific one first.
+//
+// HTTPMiddlewareWithConfig panics if the configured scope is not more specific than the scope of the app container.
+func HTTPMiddlewareWithConfig(h http.HandlerFunc, app Container, cfg HTTPMiddlewareConfig) http.HandlerFunc {
+	if cfg.Key == "" {
+		cfg.Key = ContainerKey("di")
+	}
+
+	depth := 1
+
+	if cfg.Scope != "" {
+		depth = 0
+		for i, scope := range app.SubScopes() {
+			if scope == cfg.Scope {
+				depth = i + 1
+				break
+			}
+		}
+		if depth == 0 {
+			panic(fmt.Sprintf(
+				"could not create the http middleware because `%s` is not a sub-scope of `%s`", cfg.Scope, app.Scope(),
+			))
+		}
+	}
+
 	return func(w http.ResponseWriter, r *http.Request) {
-		// create a request container from tha app container
-		ctn, err := app.SubContainer()
-		if err != nil {
-			panic(err)
+		// create the request container from the app container,
+		// with a container for each scope in between
+		containers := make([]Container, 0, depth)
+
+		deleteContainers := func() {
+			for i := len(containers) - 1; i >= 0; i-- {
+				if err := containers[i].Delete(); err != nil && cfg.LogFunc != nil {
+					cfg.LogFunc(err.Error())
+				}
+			}
 		}
-		defer func() {
-			if err := ctn.Delete(); err != nil && logFunc != nil {
-				logFunc(err.Error())
+
+		ctn := app
+
+		for len(containers) < depth {
+			sub, err := ctn.SubContainer()
+			if err != nil {
+				deleteContainers()
+				panic(err)
 			}
-		}()
+			ctn = sub.WithContext(r.Context())
+			containers = append(containers, ctn)
+		}
+
+		defer deleteContainers()
 
 		// call the handler with a new request
 		// containing the container in its context
 		h(w, r.WithContext(
-			context.WithValue(r.Context(), ContainerKey("di"), ctn),
+			context.WithValue(r.Context(), cfg.Key, ctn),
 		))
 	}
 }
 
+// ContextWithContainer returns a copy of ctx holding the Container for the ContainerKey("di") key.
+// It is the key used by HTTPMiddleware, so the Container can be retrieved with FromContext or C.
+func ContextWithContainer(ctx context.Context, ctn Container) context.Context {
+	return context.WithValue(ctx, ContainerKey("di"), ctn)
+}
+
+// FromContext retrieves the Container stored in ctx for the ContainerKey("di") key.
+// It can be used where only the context of the request is available
+// (e.g. a gRPC interceptor or a job started with the request context).
+// The boolean is false if ctx does not hold a Container.
+func FromContext(ctx context.Context) (Container, bool) {
+	c, ok := ctx.Value(ContainerKey("di")).(Container)
+	return c, ok
+}
+
 // C retrieves a Container from an interface.
 // The function panics if the Container can not be retrieved.
 //
 // The interface can be :
-// - a Container
-// - an *http.Request containing a Container in its context.Context
-//   for the ContainerKey("di") key.
+//   - a Container
+//   - an *http.Request containing a Container in its context.Context
+//     for the ContainerKey("di") key.
+//   - a context.Context containing a Container for the ContainerKey("di") key.
 //
 // The function can be changed to match the needs of your application.
 var C = func(i interface{}) Container {
-	if c, ok := i.(Container); ok {
+	switch v := i.(type) {
+	case Container:
+		return v
+	case *http.Request:
+		c, ok := FromContext(v.Context())
+		if !ok {
+			panic("could not get the container from the given *http.Request")
+		}
+		return c
+	case context.Context:
+		c, ok := FromContext(v)
+		if !ok {
+			panic("could not get the container from the given context.Context")
+		}
 		return c
 	}
 
-	r, ok := i.(*http.Request)
-	if !ok {
-		panic("could not get the container with C()")
-	}
-
-	c, ok := r.Context().Value(ContainerKey("di")).(Container)
-	if !ok {
-		panic("could not get the container from the given *http.Request")
-	}
-
-	return c
+	panic("could not get the container with C()")
 }
 
 // Get is a shortcut for C(i).Get(name).
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -68,6 +69,177 @@ func TestHTTPMiddleware(t *testing.T) {
 	require.True(t, reqClosed)
 }
 
+func TestHTTPMiddlewareDone(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+	app, _ := b.Build()
+
+	released := make(chan struct{})
+	started := make(chan struct{})
+
+	h := HTTPMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		done := C(r).Done()
+		go func() {
+			close(started)
+			<-done
+			close(released)
+		}()
+	}, app, nil)
+
+	ts := httptest.NewServer(http.HandlerFunc(h))
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL)
+	require.Nil(t, err)
+	res.Body.Close()
+
+	<-started
+
+	select {
+	case <-released:
+	case <-time.After(time.Second):
+		t.Fatal("the goroutine waiting on Done was not released after the request")
+	}
+
+	select {
+	case <-app.Done():
+		t.Fatal("the app container should not be done")
+	default:
+	}
+}
+
+func TestHTTPMiddlewareContext(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name:  "request-object",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.Context().Value(ContainerKey("test")), nil
+		},
+	})
+
+	app, _ := b.Build()
+
+	h := HTTPMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, Get(r, "request-object").(string))
+	}, app, nil)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ContainerKey("test"), "from request"))
+
+	w := httptest.NewRecorder()
+	h(w, req)
+
+	require.Equal(t, "from request", w.Body.String())
+}
+
+func TestHTTPHandlerMiddleware(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	closed := false
+
+	b.Add(&Def{
+		Name:  "request-object",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return "request", nil
+		},
+		Close: func(obj interface{}) error {
+			closed = true
+			return errors.New("close error")
+		},
+	})
+
+	app, _ := b.Build()
+
+	logs := []string{}
+
+	middleware := HTTPHandlerMiddleware(app, func(msg string) { logs = append(logs, msg) })
+
+	h := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, Get(r, "request-object").(string))
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	require.Equal(t, "request", w.Body.String())
+	require.True(t, closed)
+	require.Len(t, logs, 1)
+	require.Contains(t, logs[0], "close error")
+
+	h = middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("handler panic")
+	}))
+
+	require.PanicsWithValue(t, "handler panic", func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	})
+}
+
+func TestHTTPMiddlewareWithConfig(t *testing.T) {
+	b, _ := NewEnhancedBuilder(App, "session", Request)
+
+	closed := []string{}
+
+	b.Add(&Def{
+		Name:  "session-object",
+		Scope: "session",
+		Build: func(ctn Container) (interface{}, error) {
+			return "session", nil
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, obj.(string))
+			return nil
+		},
+	})
+	b.Add(&Def{
+		Name:  "request-object",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return "request " + ctn.Get("session-object").(string), nil
+		},
+		Close: func(obj interface{}) error {
+			closed = append(closed, obj.(string))
+			return nil
+		},
+	})
+
+	app, _ := b.Build()
+
+	key := ContainerKey("custom")
+
+	h := HTTPMiddlewareWithConfig(func(w http.ResponseWriter, r *http.Request) {
+		ctn := r.Context().Value(key).(Container)
+		require.Equal(t, Request, ctn.Scope())
+		require.Nil(t, r.Context().Value(ContainerKey("di")))
+		io.WriteString(w, ctn.Get("request-object").(string))
+	}, app, HTTPMiddlewareConfig{Key: key, Scope: Request})
+
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequest("GET", "/", nil))
+
+	require.Equal(t, "request session", w.Body.String())
+	require.Equal(t, []string{"request session", "session"}, closed)
+
+	// The default scope is the scope right after the app scope.
+	h = HTTPMiddlewareWithConfig(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, C(r).Scope())
+	}, app, HTTPMiddlewareConfig{})
+
+	w = httptest.NewRecorder()
+	h(w, httptest.NewRequest("GET", "/", nil))
+
+	require.Equal(t, "session", w.Body.String())
+
+	require.Panics(t, func() {
+		HTTPMiddlewareWithConfig(func(w http.ResponseWriter, r *http.Request) {}, app, HTTPMiddlewareConfig{Scope: App})
+	})
+	require.Panics(t, func() {
+		HTTPMiddlewareWithConfig(func(w http.ResponseWriter, r *http.Request) {}, app, HTTPMiddlewareConfig{Scope: "undefined"})
+	})
+}
+
 func TestHTTPMiddlewarePanicSubContainer(t *testing.T) {
 	b, _ := NewBuilder(App)
 	app := b.Build()
@@ -168,12 +340,44 @@ func TestC(t *testing.T) {
 		C(req)
 	})
 
+	// context.Context with a container
+	ctn = C(ContextWithContainer(context.Background(), app))
+	require.Equal(t, app, ctn)
+
+	// context.Context without a container
+	require.Panics(t, func() {
+		C(context.Background())
+	})
+
 	// random object
 	require.Panics(t, func() {
 		C("")
 	})
 }
 
+func TestFromContext(t *testing.T) {
+	b, _ := NewBuilder()
+	app := b.Build()
+
+	_, ok := FromContext(context.Background())
+	require.False(t, ok)
+
+	ctx := ContextWithContainer(context.Background(), app)
+
+	ctn, ok := FromContext(ctx)
+	require.True(t, ok)
+	require.Equal(t, app, ctn)
+
+	// The key is the one used by the HTTPMiddleware.
+	h := HTTPMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		ctn, ok := FromContext(r.Context())
+		require.True(t, ok)
+		require.Equal(t, Request, ctn.Scope())
+	}, app, nil)
+
+	h(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
 func TestRawGet(t *testing.T) {
 	b, _ := NewBuilder()
 
@@ -0,0 +1,41 @@
+package di
+
+// Logger can be registered with the SetLogger method of the EnhancedBuilder
+// to log the errors that happen in the generated containers.
+type Logger interface {
+	Error(msg string)
+}
+
+// SetLogger registers a Logger that is used by the generated Container and all its sub-containers.
+// The Logger receives a message each time an object can not be built, each time a cycle is detected,
+// and each time the Close function of an object returns an error during the deletion of a container.
+// When the build of a dependency fails, only the error of the object requested from the Container is logged,
+// as it already includes the error of the dependency.
+// The errors are still returned as usual.
+//
+// It should be registered before calling the Build method. Only the last registered Logger is used.
+// If it is nil, which is the default, nothing is logged.
+func (b *EnhancedBuilder) SetLogger(logger Logger) {
+	b.logger = logger
+}
+
+// logError gives the error message to the Logger of the Container, if there is one.
+// The panics in the Logger are recovered and ignored.
+func (c *containerConfig) logError(err error) {
+	if c.logger == nil || err == nil {
+		return
+	}
+
+	defer func() { recover() }()
+
+	c.logger.Error(err.Error())
+}
+
+// logBuildError gives the error of a failed build to the Logger of the Container.
+// The error is only logged for the object requested from the Container, not for its dependencies,
+// because their errors are included in the error of the requested object.
+func (ctn Container) logBuildError(err error) {
+	if len(ctn.buildStack) == 0 {
+		ctn.core.config.logError(err)
+	}
+}
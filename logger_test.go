@@ -0,0 +1,160 @@
+package di
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockLogger struct {
+	m        sync.Mutex
+	messages []string
+}
+
+func (l *mockLogger) Error(msg string) {
+	l.m.Lock()
+	l.messages = append(l.messages, msg)
+	l.m.Unlock()
+}
+
+type panicLogger struct{}
+
+func (panicLogger) Error(msg string) {
+	panic("logger panic")
+}
+
+func TestLogger(t *testing.T) {
+	logger := &mockLogger{}
+
+	b, _ := NewEnhancedBuilder()
+	b.SetLogger(logger)
+
+	b.Add(&Def{
+		Name: "ok",
+		Build: func(ctn Container) (interface{}, error) {
+			return "ok", nil
+		},
+	})
+	b.Add(&Def{
+		Name: "failing",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, errors.New("build error")
+		},
+	})
+	b.Add(&Def{
+		Name: "cycle",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.SafeGet("cycle")
+		},
+	})
+	b.Add(&Def{
+		Name:  "closing",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return "closing", nil
+		},
+		Close: func(obj interface{}) error {
+			return errors.New("close error")
+		},
+	})
+
+	app, _ := b.Build()
+
+	_, err := app.SafeGet("ok")
+	require.Nil(t, err)
+	require.Empty(t, logger.messages)
+
+	_, err = app.SafeGet("failing")
+	require.NotNil(t, err)
+	require.Equal(t, []string{err.Error()}, logger.messages)
+
+	logger.messages = nil
+
+	_, err = app.SafeGet("cycle")
+	require.True(t, errors.Is(err, ErrCycle))
+	require.Len(t, logger.messages, 2, "the cycle and the build failure should be logged")
+	require.Contains(t, logger.messages[0], ErrCycle.Error())
+
+	logger.messages = nil
+
+	// The Logger is also used by the sub-containers.
+	req, _ := app.SubContainer()
+	req.Get("closing")
+	err = req.Delete()
+	require.NotNil(t, err)
+	require.Len(t, logger.messages, 1)
+	require.Contains(t, logger.messages[0], "close error")
+}
+
+func TestLoggerBuildChain(t *testing.T) {
+	logger := &mockLogger{}
+
+	b, _ := NewEnhancedBuilder()
+	b.SetLogger(logger)
+
+	b.Add(&Def{
+		Name: "a",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.SafeGet("b")
+		},
+	})
+	b.Add(&Def{
+		Name:     "b",
+		Unshared: true,
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.SafeGet("c")
+		},
+	})
+	b.Add(&Def{
+		Name: "c",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, errors.New("boom")
+		},
+	})
+	b.Add(&Def{
+		Name: "cycle-1",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.SafeGet("cycle-2")
+		},
+	})
+	b.Add(&Def{
+		Name: "cycle-2",
+		Build: func(ctn Container) (interface{}, error) {
+			return ctn.SafeGet("cycle-1")
+		},
+	})
+
+	app, _ := b.Build()
+
+	_, err := app.SafeGet("a")
+	require.NotNil(t, err)
+	require.Equal(t, []string{err.Error()}, logger.messages, "only the error of the requested object should be logged")
+	require.Contains(t, err.Error(), "boom")
+
+	logger.messages = nil
+
+	_, err = app.SafeGet("cycle-1")
+	require.True(t, errors.Is(err, ErrCycle))
+	require.Len(t, logger.messages, 2, "the cycle should be logged once, along with the error of the requested object")
+	require.Contains(t, logger.messages[0], ErrCycle.Error())
+	require.Equal(t, err.Error(), logger.messages[1])
+}
+
+func TestLoggerPanic(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+	b.SetLogger(panicLogger{})
+
+	b.Add(&Def{
+		Name: "failing",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, errors.New("build error")
+		},
+	})
+
+	app, _ := b.Build()
+
+	_, err := app.SafeGet("failing")
+	require.NotNil(t, err)
+}
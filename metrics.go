@@ -0,0 +1,51 @@
+package di
+
+import "time"
+
+// Metrics receives the events of the containers generated by an EnhancedBuilder.
+// It can be registered with the WithMetrics method of the EnhancedBuilder
+// to collect metrics about the objects built and closed by the containers.
+//
+// The methods are called outside of the container locks, and their panics are recovered and ignored.
+// They can be called concurrently.
+type Metrics interface {
+	// ObjectBuilt is called each time an object is successfully built,
+	// with the time spent in the Build function, including the time spent building its dependencies.
+	ObjectBuilt(name, scope string, d time.Duration)
+	// ObjectClosed is called each time the Close function of an object is called during the deletion of a container.
+	// The error is the one returned by the Close function, or nil.
+	ObjectClosed(name string, err error)
+	// BuildFailed is called each time the Build function of an object returns an error or panics.
+	BuildFailed(name string, err error)
+}
+
+// noopMetrics is the default Metrics. It does nothing.
+type noopMetrics struct{}
+
+func (noopMetrics) ObjectBuilt(name, scope string, d time.Duration) {}
+func (noopMetrics) ObjectClosed(name string, err error)             {}
+func (noopMetrics) BuildFailed(name string, err error)              {}
+
+// WithMetrics registers a Metrics that is notified when the generated containers build and close objects.
+// It should be registered before calling the Build method. Only the last registered Metrics is used.
+func (b *EnhancedBuilder) WithMetrics(metrics Metrics) {
+	b.metrics = metrics
+}
+
+// reportBuild notifies the Metrics of the Container after an object has been built.
+func (c *containerConfig) reportBuild(def Def, d time.Duration, err error) {
+	defer func() { recover() }()
+
+	if err != nil {
+		c.metrics.BuildFailed(def.Name, err)
+	} else {
+		c.metrics.ObjectBuilt(def.Name, def.Scope, d)
+	}
+}
+
+// reportClose notifies the Metrics of the Container after an object has been closed.
+func (c *containerConfig) reportClose(name string, err error) {
+	defer func() { recover() }()
+
+	c.metrics.ObjectClosed(name, err)
+}
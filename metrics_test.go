@@ -0,0 +1,126 @@
+package di
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockMetrics struct {
+	m      sync.Mutex
+	events []string
+}
+
+func (m *mockMetrics) add(event string) {
+	m.m.Lock()
+	m.events = append(m.events, event)
+	m.m.Unlock()
+}
+
+func (m *mockMetrics) ObjectBuilt(name, scope string, d time.Duration) {
+	m.add("built " + name + " " + scope)
+}
+
+func (m *mockMetrics) ObjectClosed(name string, err error) {
+	if err != nil {
+		m.add("close failed " + name)
+		return
+	}
+	m.add("closed " + name)
+}
+
+func (m *mockMetrics) BuildFailed(name string, err error) {
+	m.add("build failed " + name)
+}
+
+type panicMetrics struct{}
+
+func (panicMetrics) ObjectBuilt(name, scope string, d time.Duration) { panic("built") }
+func (panicMetrics) ObjectClosed(name string, err error)             { panic("closed") }
+func (panicMetrics) BuildFailed(name string, err error)              { panic("failed") }
+
+func newMetricsTestBuilder() *EnhancedBuilder {
+	b, _ := NewEnhancedBuilder()
+
+	b.Add(&Def{
+		Name: "object",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+		Close: func(obj interface{}) error {
+			return nil
+		},
+	})
+	b.Add(&Def{
+		Name:  "request-object",
+		Scope: Request,
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+		Close: func(obj interface{}) error {
+			return errors.New("close error")
+		},
+	})
+	b.Add(&Def{
+		Name: "without-close",
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockA{}, nil
+		},
+	})
+	b.Add(&Def{
+		Name: "error",
+		Build: func(ctn Container) (interface{}, error) {
+			return nil, errors.New("build error")
+		},
+	})
+	b.Add(&Def{
+		Name: "panic",
+		Build: func(ctn Container) (interface{}, error) {
+			panic("build panic")
+		},
+	})
+
+	return b
+}
+
+func TestMetrics(t *testing.T) {
+	metrics := &mockMetrics{}
+
+	b := newMetricsTestBuilder()
+	b.WithMetrics(metrics)
+	app, _ := b.Build()
+	request, _ := app.SubContainer()
+
+	request.Get("request-object")
+	request.Get("object")
+	request.Get("without-close")
+	request.SafeGet("error")
+	request.SafeGet("panic")
+	request.Delete()
+	app.Delete()
+
+	require.Equal(t, []string{
+		"built request-object request",
+		"built object app",
+		"built without-close app",
+		"build failed error",
+		"build failed panic",
+		"close failed request-object",
+		"closed object",
+	}, metrics.events)
+}
+
+func TestMetricsPanic(t *testing.T) {
+	b := newMetricsTestBuilder()
+	b.WithMetrics(panicMetrics{})
+	app, _ := b.Build()
+
+	_, err := app.SafeGet("object")
+	require.Nil(t, err)
+	_, err = app.SafeGet("error")
+	require.NotNil(t, err)
+	require.Nil(t, app.Delete())
+}
@@ -0,0 +1,32 @@
+package di
+
+import "fmt"
+
+// WithObjectWrapper registers a function that can replace the objects built by the generated containers.
+// It is called once for each built object, before the object is stored in the container,
+// so the returned value is the one retrieved by all the calls to Get.
+// It receives the definition of the object, and it can return the original object
+// for the definitions that should not be wrapped.
+// The Close function of the definition receives the wrapped object.
+//
+// If the wrapper panics, the object is not stored and Get returns an error.
+// It should be registered before calling the Build method. Only the last registered wrapper is used.
+func (b *EnhancedBuilder) WithObjectWrapper(wrapper func(def Def, obj interface{}) interface{}) {
+	b.objectWrapper = wrapper
+}
+
+// wrapObject applies the object wrapper of the Container on a newly built object.
+func (c *containerConfig) wrapObject(def Def, obj interface{}) (wrapped interface{}, err error) {
+	if c.objectWrapper == nil {
+		return obj, nil
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			wrapped = nil
+			err = fmt.Errorf("could not build `%s` because the object wrapper panicked: %+v", def.Name, r)
+		}
+	}()
+
+	return c.objectWrapper(def, obj), nil
+}
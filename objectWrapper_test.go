@@ -0,0 +1,114 @@
+package di
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type wrappedObject struct {
+	name string
+	obj  interface{}
+}
+
+func TestWithObjectWrapper(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	numWrapped := 0
+	closed := []interface{}{}
+
+	b.WithObjectWrapper(func(def Def, obj interface{}) interface{} {
+		if _, ok := def.Meta["raw"]; ok {
+			return obj
+		}
+		numWrapped++
+		return &wrappedObject{name: def.Name, obj: obj}
+	})
+
+	shared := &Def{
+		Name:  "shared",
+		Build: func(ctn Container) (interface{}, error) { return 1, nil },
+		Close: func(obj interface{}) error {
+			closed = append(closed, obj)
+			return nil
+		},
+	}
+	unshared := &Def{
+		Name:     "unshared",
+		Unshared: true,
+		Build:    func(ctn Container) (interface{}, error) { return 2, nil },
+	}
+	raw := &Def{
+		Name:  "raw",
+		Meta:  map[string]interface{}{"raw": true},
+		Build: func(ctn Container) (interface{}, error) { return 3, nil },
+	}
+
+	require.Nil(t, b.Add(shared))
+	require.Nil(t, b.Add(unshared))
+	require.Nil(t, b.Add(raw))
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	obj := app.Get(shared)
+	require.Equal(t, &wrappedObject{name: "shared", obj: 1}, obj)
+	require.True(t, obj == app.Get(shared), "the wrapped object should be cached")
+	require.Equal(t, 1, numWrapped)
+
+	require.Equal(t, &wrappedObject{name: "unshared", obj: 2}, app.Get(unshared))
+	require.Equal(t, &wrappedObject{name: "unshared", obj: 2}, app.Get(unshared))
+	require.Equal(t, 3, numWrapped)
+
+	require.Equal(t, 3, app.Get(raw))
+	require.Equal(t, 3, numWrapped)
+
+	require.Nil(t, app.Delete())
+	require.Equal(t, []interface{}{obj}, closed)
+}
+
+func TestWithObjectWrapperPanic(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	b.WithObjectWrapper(func(def Def, obj interface{}) interface{} {
+		panic("wrapper error")
+	})
+
+	def := &Def{
+		Name:  "object",
+		Build: func(ctn Container) (interface{}, error) { return 1, nil },
+	}
+
+	require.Nil(t, b.Add(def))
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	_, err = app.SafeGet(def)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "the object wrapper panicked: wrapper error")
+}
+
+func TestWithObjectWrapperPanicCleanup(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	b.WithObjectWrapper(func(def Def, obj interface{}) interface{} {
+		panic("wrapper error")
+	})
+
+	cleaned := false
+
+	require.Nil(t, b.Add(&Def{
+		Name: "object",
+		BuildWithCleanup: func(ctn Container) (interface{}, func() error, error) {
+			return 1, func() error { cleaned = true; return nil }, nil
+		},
+	}))
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	_, err = app.SafeGet("object")
+	require.NotNil(t, err)
+	require.True(t, cleaned, "the cleanup function should be called if the object wrapper fails")
+}
@@ -1,5 +1,7 @@
 package di
 
+import "fmt"
+
 // App is the name of the application scope.
 const App = "app"
 
@@ -55,3 +57,124 @@ func (l ScopeList) Contains(scope string) bool {
 
 	return false
 }
+
+// ValidateScopes checks that a list of scopes can be used to create a builder.
+// The list must not be empty, and the scopes must be non-empty strings without duplicates.
+// It returns the same error as NewEnhancedBuilder would with these scopes.
+func ValidateScopes(scopes []string) error {
+	return checkBuilderScopes(scopes)
+}
+
+// checkDependsOn checks that the names in the DependsOn fields of the definitions are defined,
+// and that the definitions do not depend on definitions in a more specific scope.
+func checkDependsOn(definitions []Def, indexesByName map[string]int, definitionScopeLevels []int) error {
+	for index := range definitions {
+		if err := checkDefinitionDependsOn(index, definitions, indexesByName, definitionScopeLevels); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkDefinitionDependsOn works like checkDependsOn, but it only checks the definition at the given index.
+func checkDefinitionDependsOn(index int, definitions []Def, indexesByName map[string]int, definitionScopeLevels []int) error {
+	def := definitions[index]
+
+	for _, name := range def.DependsOn {
+		depIndex, ok := indexesByName[name]
+		if !ok {
+			return fmt.Errorf("the definition `%s` depends on `%s` which is not defined", def.Name, name)
+		}
+
+		if definitionScopeLevels[depIndex] > definitionScopeLevels[index] {
+			return fmt.Errorf(
+				"the definition `%s` in scope `%s` can not depend on `%s` in the more specific scope `%s`",
+				def.Name, def.Scope, name, definitions[depIndex].Scope,
+			)
+		}
+	}
+
+	return nil
+}
+
+// inferScopes sets the scope of the definitions without Scope
+// to the most specific scope of the definitions in their DependsOn field.
+// It also checks that the definitions do not depend on definitions in a more specific scope.
+func inferScopes(definitions []Def, scopes ScopeList) error {
+	indexesByName := make(map[string]int, len(definitions))
+	for index, def := range definitions {
+		indexesByName[def.Name] = index
+	}
+
+	// levels contains the scope level of each definition, -1 if it is not known yet.
+	// visiting is used to detect the cycles.
+	levels := make([]int, len(definitions))
+	visiting := make([]bool, len(definitions))
+	for i := range levels {
+		levels[i] = -1
+	}
+
+	var inferLevel func(index int) (int, error)
+
+	inferLevel = func(index int) (int, error) {
+		if levels[index] >= 0 {
+			return levels[index], nil
+		}
+
+		def := definitions[index]
+
+		if visiting[index] {
+			return 0, fmt.Errorf("could not infer the scope of `%s` because there is a cycle in the DependsOn fields", def.Name)
+		}
+		visiting[index] = true
+
+		level := 0
+		for i, s := range scopes {
+			if s == def.Scope {
+				level = i
+			}
+		}
+
+		for _, name := range def.DependsOn {
+			depIndex, ok := indexesByName[name]
+			if !ok {
+				return 0, fmt.Errorf("the definition `%s` depends on `%s` which is not defined", def.Name, name)
+			}
+
+			depLevel, err := inferLevel(depIndex)
+			if err != nil {
+				return 0, err
+			}
+
+			if def.Scope == "" {
+				if depLevel > level {
+					level = depLevel
+				}
+				continue
+			}
+
+			if depLevel > level {
+				return 0, fmt.Errorf(
+					"the definition `%s` in scope `%s` can not depend on `%s` in the more specific scope `%s`",
+					def.Name, def.Scope, name, scopes[depLevel],
+				)
+			}
+		}
+
+		visiting[index] = false
+		levels[index] = level
+
+		return level, nil
+	}
+
+	for index := range definitions {
+		level, err := inferLevel(index)
+		if err != nil {
+			return err
+		}
+		definitions[index].Scope = scopes[level]
+	}
+
+	return nil
+}
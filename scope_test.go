@@ -27,3 +27,12 @@ func TestScopeList(t *testing.T) {
 	require.Equal(t, ScopeList{}, list.SubScopes("c"))
 	require.Equal(t, ScopeList{}, list.SubScopes("x"))
 }
+
+func TestValidateScopes(t *testing.T) {
+	require.Nil(t, ValidateScopes([]string{App, Request, SubRequest}))
+	require.Nil(t, ValidateScopes([]string{"a"}))
+	require.NotNil(t, ValidateScopes(nil))
+	require.NotNil(t, ValidateScopes([]string{}))
+	require.NotNil(t, ValidateScopes([]string{"a", ""}))
+	require.NotNil(t, ValidateScopes([]string{"a", "b", "a"}))
+}
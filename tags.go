@@ -0,0 +1,65 @@
+package di
+
+import "sort"
+
+// DefinitionsForTag returns the definitions having a tag with the given name,
+// in the order they were inserted in the builder.
+func (ctn Container) DefinitionsForTag(name string) []Def {
+	defs := []Def{}
+
+	for _, def := range ctn.core.definitions {
+		if def.hasTag(name) {
+			defs = append(defs, def)
+		}
+	}
+
+	return defs
+}
+
+// TagIsDefined returns true if at least one definition has a tag with the given name.
+func (ctn Container) TagIsDefined(name string) bool {
+	for _, def := range ctn.core.definitions {
+		if def.hasTag(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefinitionsForTag returns the definitions registered at this point having a tag with the given name,
+// in the order they were inserted in the builder.
+func (b *EnhancedBuilder) DefinitionsForTag(name string) []Def {
+	defs := []Def{}
+
+	for _, def := range b.definitions {
+		if def.hasTag(name) {
+			defs = append(defs, def)
+		}
+	}
+
+	sort.Slice(defs, func(i, j int) bool {
+		return b.insertionOrder[defs[i].Name] < b.insertionOrder[defs[j].Name]
+	})
+
+	return defs
+}
+
+// TagIsDefined returns true if at least one definition registered at this point has a tag with the given name.
+func (b *EnhancedBuilder) TagIsDefined(name string) bool {
+	for _, def := range b.definitions {
+		if def.hasTag(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTag returns true if the definition has a tag with the given name.
+func (d *Def) hasTag(name string) bool {
+	for _, tag := range d.Tags {
+		if tag.Name == name {
+			return true
+		}
+	}
+	return false
+}
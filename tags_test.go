@@ -0,0 +1,40 @@
+package di
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefinitionsForTag(t *testing.T) {
+	b, _ := NewEnhancedBuilder()
+
+	build := func(ctn Container) (interface{}, error) { return nil, nil }
+
+	require.Nil(t, b.Add(&Def{Name: "h2", Tags: []Tag{{Name: "route"}}, Build: build}))
+	require.Nil(t, b.Add(&Def{Name: "other", Tags: []Tag{{Name: "other"}}, Build: build}))
+	require.Nil(t, b.Add(&Def{Name: "h1", Tags: []Tag{{Name: "other"}, {Name: "route"}}, Build: build}))
+	require.Nil(t, b.Add(&Def{Name: "untagged", Build: build}))
+
+	names := func(defs []Def) []string {
+		res := []string{}
+		for _, def := range defs {
+			res = append(res, def.Name)
+		}
+		return res
+	}
+
+	require.Equal(t, []string{"h2", "h1"}, names(b.DefinitionsForTag("route")))
+	require.Equal(t, []string{}, names(b.DefinitionsForTag("undefined")))
+	require.True(t, b.TagIsDefined("other"))
+	require.False(t, b.TagIsDefined("undefined"))
+
+	app, err := b.Build()
+	require.Nil(t, err)
+
+	require.Equal(t, []string{"h2", "h1"}, names(app.DefinitionsForTag("route")))
+	require.Equal(t, []string{"other", "h1"}, names(app.DefinitionsForTag("other")))
+	require.Equal(t, []string{}, names(app.DefinitionsForTag("undefined")))
+	require.True(t, app.TagIsDefined("route"))
+	require.False(t, app.TagIsDefined("undefined"))
+}
@@ -0,0 +1,72 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// TypeResolution determines which definition is used
+// when an object is retrieved by type and several definitions match this type.
+type TypeResolution int
+
+const (
+	// LastWins selects the last matching definition inserted in the builder.
+	// It is the default TypeResolution.
+	LastWins TypeResolution = iota
+	// FirstWins selects the first matching definition inserted in the builder.
+	FirstWins
+	// Primary selects the matching definition with its Primary field set to true.
+	// If none of the matching definitions is primary, the last one inserted in the builder is used.
+	// If more than one matching definition is primary, an error is returned.
+	Primary
+	// AmbiguityError returns an error if more than one definition matches the type.
+	AmbiguityError
+)
+
+// WithTypeResolution sets the TypeResolution used by the Container
+// to choose a definition when several definitions match the type given to
+// Get, SafeGet, Fill, the unscoped getters or TypeAccessor.
+// The default value is LastWins.
+func WithTypeResolution(resolution TypeResolution) BuildOption {
+	return func(o *buildOptions) {
+		o.typeResolution = resolution
+	}
+}
+
+// selectTypeIndex chooses the index of the definition to use for the given type,
+// among the non-empty list of matching indexes, depending on the TypeResolution of the Container.
+func (core *containerCore) selectTypeIndex(typ reflect.Type, indexes []int) (int, error) {
+	if len(indexes) == 1 {
+		return indexes[0], nil
+	}
+
+	switch core.config.typeResolution {
+	case FirstWins:
+		return indexes[0], nil
+	case Primary:
+		primary := -1
+		for _, index := range indexes {
+			if !core.definitions[index].Primary {
+				continue
+			}
+			if primary >= 0 {
+				return 0, fmt.Errorf(
+					"could not get type `%s`%s because both `%s` and `%s` are primary",
+					typ, core.nameSuffix(), core.definitions[primary].Name, core.definitions[index].Name,
+				)
+			}
+			primary = index
+		}
+		if primary >= 0 {
+			return primary, nil
+		}
+	case AmbiguityError:
+		names := make([]string, len(indexes))
+		for i, index := range indexes {
+			names[i] = core.definitions[index].Name
+		}
+		return 0, fmt.Errorf("could not get type `%s`%s because it matches several definitions: %v", typ, core.nameSuffix(), names)
+	}
+
+	return indexes[len(indexes)-1], nil
+}
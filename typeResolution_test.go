@@ -0,0 +1,82 @@
+package di
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTypeResolutionTestBuilder(primaries ...string) *EnhancedBuilder {
+	b, _ := NewEnhancedBuilder()
+
+	for _, name := range []string{"a1", "a2", "a3"} {
+		name := name
+		b.Add(&Def{
+			Name:    name,
+			Is:      NewIs(&mockA{}),
+			Primary: containsString(primaries, name),
+			Build: func(ctn Container) (interface{}, error) {
+				return &mockA{SField: name}, nil
+			},
+		})
+	}
+
+	b.Add(&Def{
+		Name: "b",
+		Is:   NewIs(&mockB{}),
+		Build: func(ctn Container) (interface{}, error) {
+			return &mockB{}, nil
+		},
+	})
+
+	return b
+}
+
+func TestTypeResolution(t *testing.T) {
+	typ := reflect.TypeOf(&mockA{})
+
+	tests := []struct {
+		descr       string
+		primaries   []string
+		opts        []BuildOption
+		expected    string
+		expectedErr bool
+	}{
+		{descr: "default", expected: "a3"},
+		{descr: "last wins", opts: []BuildOption{WithTypeResolution(LastWins)}, expected: "a3"},
+		{descr: "first wins", opts: []BuildOption{WithTypeResolution(FirstWins)}, expected: "a1"},
+		{descr: "primary", primaries: []string{"a2"}, opts: []BuildOption{WithTypeResolution(Primary)}, expected: "a2"},
+		{descr: "no primary", opts: []BuildOption{WithTypeResolution(Primary)}, expected: "a3"},
+		{descr: "primary ignored", primaries: []string{"a2"}, expected: "a3"},
+		{descr: "several primaries", primaries: []string{"a1", "a2"}, opts: []BuildOption{WithTypeResolution(Primary)}, expectedErr: true},
+		{descr: "ambiguity error", opts: []BuildOption{WithTypeResolution(AmbiguityError)}, expectedErr: true},
+	}
+
+	for _, test := range tests {
+		app, _ := newTypeResolutionTestBuilder(test.primaries...).Build(test.opts...)
+		request, _ := app.SubContainer()
+
+		obj, err := app.SafeGet(typ)
+		unscopedObj, unscopedErr := request.UnscopedSafeGet(typ)
+		var filled *mockA
+		fillErr := app.Fill(typ, &filled)
+
+		if test.expectedErr {
+			require.NotNil(t, err, test.descr)
+			require.NotNil(t, unscopedErr, test.descr)
+			require.NotNil(t, fillErr, test.descr)
+		} else {
+			require.Nil(t, err, test.descr)
+			require.Equal(t, test.expected, obj.(*mockA).SField, test.descr)
+			require.Nil(t, unscopedErr, test.descr)
+			require.Equal(t, test.expected, unscopedObj.(*mockA).SField, test.descr)
+			require.Nil(t, fillErr, test.descr)
+			require.Equal(t, test.expected, filled.SField, test.descr)
+		}
+
+		// A single match is never ambiguous.
+		_, err = app.SafeGet(reflect.TypeOf(&mockB{}))
+		require.Nil(t, err, test.descr)
+	}
+}
@@ -4,9 +4,22 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 )
 
+// dependencyTracker stores the dependencies inside a container.
+// These dependencies are then used to determine the order
+// that should be used to close the objects.
+// The vertices are the indexes used in the containerCore dependencies field.
+type dependencyTracker interface {
+	AddVertex(v int)
+	AddEdge(from, to int)
+	RemoveVertex(v int)
+	Edges() [][2]int
+	TopologicalOrdering() ([]int, error)
+}
+
 // graph is a Directed Acyclic Graph.
 // It is used to store the dependencies inside a container.
 // These dependencies are then used to determine the order
@@ -69,6 +82,42 @@ func (g *graph) AddEdge(from, to int) {
 	g.vertices[to].numIn++
 }
 
+// RemoveVertex removes a vertex and all its edges from the graph.
+func (g *graph) RemoveVertex(v int) {
+	vertex, ok := g.vertices[v]
+	if !ok {
+		return
+	}
+
+	for _, out := range vertex.out {
+		g.vertices[out].numIn--
+	}
+
+	delete(g.vertices, v)
+	g.verticeSlice = removeInt(g.verticeSlice, v)
+
+	for _, u := range g.verticeSlice {
+		if _, ok := g.vertices[u].outMap[v]; ok {
+			delete(g.vertices[u].outMap, v)
+			g.vertices[u].out = removeInt(g.vertices[u].out, v)
+		}
+	}
+}
+
+// Edges returns the edges of the graph.
+// The edges are sorted by the insertion order of their origin vertex.
+func (g *graph) Edges() [][2]int {
+	edges := [][2]int{}
+
+	for _, v := range g.verticeSlice {
+		for _, out := range g.vertices[v].out {
+			edges = append(edges, [2]int{v, out})
+		}
+	}
+
+	return edges
+}
+
 // TopologicalOrdering returns a valid topological sort.
 // It implements Kahn's algorithm.
 // If there is a cycle in the graph, an error is returned.
@@ -104,6 +153,222 @@ func (g *graph) TopologicalOrdering() ([]int, error) {
 	return l, nil
 }
 
+// StronglyConnectedComponents returns the groups of vertices that are part of a cycle.
+// Two vertices are in the same group if there is a path from each of them to the other.
+// A vertex with an edge to itself is also returned, alone in its group.
+// The vertices of a group are sorted, and the groups are sorted by their first vertex.
+// It implements Tarjan's algorithm.
+func (g *graph) StronglyConnectedComponents() [][]int {
+	components := [][]int{}
+
+	index := 0
+	indexes := map[int]int{}
+	lowLinks := map[int]int{}
+	onStack := map[int]bool{}
+	stack := []int{}
+
+	var connect func(v int)
+	connect = func(v int) {
+		indexes[v] = index
+		lowLinks[v] = index
+		index++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range g.vertices[v].out {
+			if _, visited := indexes[w]; !visited {
+				connect(w)
+				if lowLinks[w] < lowLinks[v] {
+					lowLinks[v] = lowLinks[w]
+				}
+			} else if onStack[w] && indexes[w] < lowLinks[v] {
+				lowLinks[v] = indexes[w]
+			}
+		}
+
+		if lowLinks[v] != indexes[v] {
+			return
+		}
+
+		component := []int{}
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			component = append(component, w)
+			if w == v {
+				break
+			}
+		}
+
+		if _, selfLoop := g.vertices[v].outMap[v]; len(component) > 1 || selfLoop {
+			sort.Ints(component)
+			components = append(components, component)
+		}
+	}
+
+	for _, v := range g.verticeSlice {
+		if _, visited := indexes[v]; !visited {
+			connect(v)
+		}
+	}
+
+	sort.Slice(components, func(i, j int) bool {
+		return components[i][0] < components[j][0]
+	})
+
+	return components
+}
+
+// arrayGraph is a Directed Acyclic Graph like graph,
+// but the vertices are stored in a slice instead of a map.
+// It allocates less than graph when the vertices are dense,
+// which is the case when a container builds most of its definitions.
+type arrayGraph struct {
+	verticeSlice []int
+	// vertices contains the vertex data at the position returned by arrayGraphPosition.
+	vertices []arrayGraphVertex
+}
+
+// arrayGraphVertex contains the vertex data.
+type arrayGraphVertex struct {
+	exists   bool
+	numIn    int
+	numInTmp int
+	out      []int
+}
+
+// newArrayGraph creates a new arrayGraph.
+func newArrayGraph() *arrayGraph {
+	return &arrayGraph{
+		verticeSlice: []int{},
+		vertices:     []arrayGraphVertex{},
+	}
+}
+
+// arrayGraphPosition returns the position of a vertex in the vertices slice.
+// The positive vertices are at even positions and the negative ones at odd positions.
+func arrayGraphPosition(v int) int {
+	if v >= 0 {
+		return 2 * v
+	}
+	return -2*v - 1
+}
+
+// vertex returns the data of a vertex that is already in the graph.
+func (g *arrayGraph) vertex(v int) *arrayGraphVertex {
+	return &g.vertices[arrayGraphPosition(v)]
+}
+
+// AddVertex adds a vertex to the graph.
+func (g *arrayGraph) AddVertex(v int) {
+	pos := arrayGraphPosition(v)
+
+	if pos >= len(g.vertices) {
+		vertices := make([]arrayGraphVertex, 2*pos+2)
+		copy(vertices, g.vertices)
+		g.vertices = vertices
+	}
+
+	if g.vertices[pos].exists {
+		return
+	}
+
+	g.verticeSlice = append(g.verticeSlice, v)
+	g.vertices[pos].exists = true
+}
+
+// AddEdge adds an edge to the graph.
+func (g *arrayGraph) AddEdge(from, to int) {
+	g.AddVertex(from)
+	g.AddVertex(to)
+
+	// check if the edge is already registered
+	for _, out := range g.vertex(from).out {
+		if out == to {
+			return
+		}
+	}
+
+	// update the vertices
+	g.vertex(from).out = append(g.vertex(from).out, to)
+	g.vertex(to).numIn++
+}
+
+// RemoveVertex removes a vertex and all its edges from the graph.
+func (g *arrayGraph) RemoveVertex(v int) {
+	pos := arrayGraphPosition(v)
+	if pos >= len(g.vertices) || !g.vertices[pos].exists {
+		return
+	}
+
+	for _, out := range g.vertices[pos].out {
+		g.vertex(out).numIn--
+	}
+
+	g.vertices[pos] = arrayGraphVertex{}
+	g.verticeSlice = removeInt(g.verticeSlice, v)
+
+	for _, u := range g.verticeSlice {
+		vertex := g.vertex(u)
+		for _, out := range vertex.out {
+			if out == v {
+				vertex.out = removeInt(vertex.out, v)
+				break
+			}
+		}
+	}
+}
+
+// Edges returns the edges of the graph.
+// The edges are sorted by the insertion order of their origin vertex.
+func (g *arrayGraph) Edges() [][2]int {
+	edges := [][2]int{}
+
+	for _, v := range g.verticeSlice {
+		for _, out := range g.vertex(v).out {
+			edges = append(edges, [2]int{v, out})
+		}
+	}
+
+	return edges
+}
+
+// TopologicalOrdering returns a valid topological sort.
+// It implements Kahn's algorithm, like graph.TopologicalOrdering.
+func (g *arrayGraph) TopologicalOrdering() ([]int, error) {
+	l := make([]int, 0, len(g.verticeSlice))
+	q := []int{}
+
+	for _, v := range g.verticeSlice {
+		vertex := g.vertex(v)
+		if vertex.numIn == 0 {
+			q = append(q, v)
+		}
+		vertex.numInTmp = vertex.numIn
+	}
+
+	for len(q) > 0 {
+		n := q[len(q)-1]
+		q = q[:len(q)-1]
+		l = append(l, n)
+
+		for _, m := range g.vertex(n).out {
+			vertex := g.vertex(m)
+			vertex.numInTmp--
+			if vertex.numInTmp == 0 {
+				q = append(q, m)
+			}
+		}
+	}
+
+	if len(l) != len(g.verticeSlice) {
+		return append([]int{}, g.verticeSlice...), errors.New("a cycle has been found in the dependencies")
+	}
+
+	return l, nil
+}
+
 // multiErrBuilder can accumulate errors.
 type multiErrBuilder struct {
 	errs []error
@@ -124,26 +389,129 @@ func (b *multiErrBuilder) Build() error {
 		return nil
 	}
 
-	msgs := make([]string, len(b.errs))
+	errs := make([]error, len(b.errs))
+	copy(errs, b.errs)
+
+	return &multiErr{errs: errs}
+}
+
+// multiErr is the error returned by the multiErrBuilder.
+// Its message contains the messages of all the accumulated errors.
+// The accumulated errors can be checked with errors.Is and errors.As.
+type multiErr struct {
+	errs []error
+}
+
+// Error returns the messages of the accumulated errors.
+func (e *multiErr) Error() string {
+	msgs := make([]string, len(e.errs))
 
-	for i, err := range b.errs {
+	for i, err := range e.errs {
 		msgs[i] = err.Error()
 	}
 
-	return errors.New(strings.Join(msgs, " AND "))
+	return strings.Join(msgs, " AND ")
+}
+
+// Unwrap returns the accumulated errors.
+func (e *multiErr) Unwrap() []error {
+	return e.errs
+}
+
+// Is returns true if one of the accumulated errors matches target.
+// errors.Is only uses the Unwrap method returning a slice since go 1.20,
+// so Is is needed for the older versions.
+func (e *multiErr) Is(target error) bool {
+	for _, err := range e.errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first accumulated error that matches target, like errors.As.
+// As Is, it is needed for the versions of go older than 1.20.
+func (e *multiErr) As(target interface{}) bool {
+	for _, err := range e.errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
 }
 
-// fill copies src in dest. dest should be a pointer to src type.
+// fill copies src in dest. dest should be a pointer to src type,
+// or a pointer to a type src can be assigned to, like an interface implemented by src.
+// If src is nil, dest can also be a pointer to a type that accepts nil, like an interface or a pointer.
 func fill(src, dest interface{}) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			d := reflect.TypeOf(dest)
-			s := reflect.TypeOf(src)
-			err = fmt.Errorf("the fill destination should be a pointer to a `%s`, but you used a `%s`", s, d)
+			err = formatFillError(src, dest)
 		}
 	}()
 
-	reflect.ValueOf(dest).Elem().Set(reflect.ValueOf(src))
+	d := reflect.ValueOf(dest)
+	if d.Kind() != reflect.Ptr || d.IsNil() {
+		return formatFillError(src, dest)
+	}
 
-	return err
+	elem := d.Elem()
+
+	if src == nil {
+		switch elem.Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+			elem.Set(reflect.Zero(elem.Type()))
+			return nil
+		default:
+			return formatFillError(src, dest)
+		}
+	}
+
+	s := reflect.ValueOf(src)
+	if !s.Type().AssignableTo(elem.Type()) {
+		return formatFillError(src, dest)
+	}
+
+	elem.Set(s)
+
+	return nil
+}
+
+// formatFillError formats the error returned by fill when src can not be copied in dest.
+func formatFillError(src, dest interface{}) error {
+	d := reflect.TypeOf(dest)
+	s := reflect.TypeOf(src)
+	return fmt.Errorf("the fill destination should be a pointer to a `%s`, but you used a `%s`", s, d)
+}
+
+// removeInt returns the slice without the first occurrence of the given int.
+// The slice is modified in place.
+func removeInt(slice []int, n int) []int {
+	for i, elem := range slice {
+		if elem == n {
+			return append(slice[:i], slice[i+1:]...)
+		}
+	}
+	return slice
+}
+
+// containsString returns true if the slice contains the given string.
+func containsString(slice []string, s string) bool {
+	for _, elem := range slice {
+		if elem == s {
+			return true
+		}
+	}
+	return false
+}
+
+// containsInt returns true if the slice contains the given int.
+func containsInt(slice []int, n int) bool {
+	for _, elem := range slice {
+		if elem == n {
+			return true
+		}
+	}
+	return false
 }
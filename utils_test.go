@@ -2,11 +2,17 @@ package di
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 )
 
+var dependencyTrackerConstructors = map[string]func() dependencyTracker{
+	"graph":      func() dependencyTracker { return newGraph() },
+	"arrayGraph": func() dependencyTracker { return newArrayGraph() },
+}
+
 func TestGraph(t *testing.T) {
 	tests := []struct {
 		descr       string
@@ -74,26 +80,28 @@ func TestGraph(t *testing.T) {
 		},
 	}
 
-	for _, test := range tests {
-		g := newGraph()
+	for _, newTracker := range dependencyTrackerConstructors {
+		for _, test := range tests {
+			g := newTracker()
 
-		for _, v := range test.vertices {
-			g.AddVertex(v)
-		}
+			for _, v := range test.vertices {
+				g.AddVertex(v)
+			}
 
-		for _, e := range test.edges {
-			g.AddEdge(e[0], e[1])
-		}
+			for _, e := range test.edges {
+				g.AddEdge(e[0], e[1])
+			}
 
-		l, err := g.TopologicalOrdering()
+			l, err := g.TopologicalOrdering()
 
-		if test.expectedErr {
-			require.NotNil(t, err, test.descr)
-		} else {
-			require.Nil(t, err, test.descr)
-		}
+			if test.expectedErr {
+				require.NotNil(t, err, test.descr)
+			} else {
+				require.Nil(t, err, test.descr)
+			}
 
-		require.Equal(t, test.expected, l, test.descr)
+			require.Equal(t, test.expected, l, test.descr)
+		}
 	}
 }
 
@@ -117,8 +125,33 @@ func TestMultiErrBuilder(t *testing.T) {
 	err = builder.Build()
 	require.NotNil(t, err)
 	require.Equal(t, "a AND b AND c", err.Error())
+
+	target := errors.New("target")
+	builder.Add(fmt.Errorf("wrapped: %w", target))
+	err = builder.Build()
+	require.True(t, errors.Is(err, target))
+	require.False(t, errors.Is(err, errors.New("other")))
+
+	var typedErr *multiErrTestError
+	builder.Add(fmt.Errorf("wrapped: %w", &multiErrTestError{msg: "typed"}))
+	err = builder.Build()
+	require.True(t, errors.As(err, &typedErr))
+	require.Equal(t, "typed", typedErr.msg)
+
+	// The Is and As methods are used by errors.Is and errors.As before go 1.20.
+	multi := err.(*multiErr)
+	require.True(t, multi.Is(target))
+	require.False(t, multi.Is(errors.New("other")))
+	typedErr = nil
+	require.True(t, multi.As(&typedErr))
+	require.Equal(t, "typed", typedErr.msg)
+	require.False(t, multi.As(new(*buildError)))
 }
 
+type multiErrTestError struct{ msg string }
+
+func (e *multiErrTestError) Error() string { return e.msg }
+
 func TestFillUtil(t *testing.T) {
 	var err error
 
@@ -129,4 +162,119 @@ func TestFillUtil(t *testing.T) {
 
 	err = fill(100, i)
 	require.NotNil(t, err)
+
+	err = fill("100", &i)
+	require.NotNil(t, err)
+	require.Equal(t, "the fill destination should be a pointer to a `string`, but you used a `*int`", err.Error())
+
+	var ip *int
+	err = fill(100, ip)
+	require.NotNil(t, err, "the destination can not be a nil pointer")
+
+	// The destination can be an interface implemented by the source.
+	var stringer fmt.Stringer
+	err = fill(&mockStringer{value: "s"}, &stringer)
+	require.Nil(t, err)
+	require.Equal(t, "s", stringer.String())
+
+	var e error
+	err = fill(&mockStringer{value: "s"}, &e)
+	require.NotNil(t, err)
+
+	// A nil source can only fill a destination that accepts nil.
+	err = fill(nil, &stringer)
+	require.Nil(t, err)
+	require.Nil(t, stringer)
+
+	err = fill(nil, &i)
+	require.NotNil(t, err)
+}
+
+type mockStringer struct {
+	value string
+}
+
+func (s *mockStringer) String() string {
+	return s.value
+}
+
+func TestGraphStronglyConnectedComponents(t *testing.T) {
+	g := newGraph()
+	require.Empty(t, g.StronglyConnectedComponents())
+
+	g.AddEdge(1, 2)
+	g.AddEdge(2, 3)
+	g.AddEdge(3, 1)
+	g.AddEdge(3, 4)
+	g.AddEdge(4, 5)
+	g.AddEdge(5, 4)
+	g.AddEdge(6, 6)
+	g.AddEdge(7, 1)
+	g.AddVertex(8)
+
+	require.Equal(t, [][]int{{1, 2, 3}, {4, 5}, {6}}, g.StronglyConnectedComponents())
+}
+
+func TestGraphEdges(t *testing.T) {
+	for name, newTracker := range dependencyTrackerConstructors {
+		g := newTracker()
+		require.Empty(t, g.Edges(), name)
+
+		g.AddEdge(1, 2)
+		g.AddEdge(1, 3)
+		g.AddEdge(3, -1)
+		g.AddVertex(4)
+		g.AddEdge(1, 2)
+		g.AddEdge(-2, 0)
+
+		require.Equal(t, [][2]int{{1, 2}, {1, 3}, {3, -1}, {-2, 0}}, g.Edges(), name)
+	}
+}
+
+func TestGraphRemoveVertex(t *testing.T) {
+	for name, newTracker := range dependencyTrackerConstructors {
+		g := newTracker()
+
+		g.AddEdge(1, 2)
+		g.AddEdge(2, 3)
+		g.AddEdge(1, 3)
+		g.AddEdge(-1, 2)
+		g.RemoveVertex(2)
+		g.RemoveVertex(4)
+
+		require.Equal(t, [][2]int{{1, 3}}, g.Edges(), name)
+
+		l, err := g.TopologicalOrdering()
+		require.Nil(t, err, name)
+		require.ElementsMatch(t, []int{1, 3, -1}, l, name)
+
+		g.AddEdge(3, 2)
+		l, err = g.TopologicalOrdering()
+		require.Nil(t, err, name)
+		require.Len(t, l, 4, name)
+	}
+}
+
+func benchmarkDependencyTracker(b *testing.B, newTracker func() dependencyTracker) {
+	for i := 0; i < b.N; i++ {
+		g := newTracker()
+
+		// Objects depending on the previous ones, with a few unshared objects.
+		for v := 1; v < 10000; v++ {
+			g.AddEdge(v-1, v)
+			if v%10 == 0 {
+				g.AddEdge(v, -v/10)
+			}
+		}
+
+		g.TopologicalOrdering()
+	}
+}
+
+func BenchmarkGraph(b *testing.B) {
+	benchmarkDependencyTracker(b, dependencyTrackerConstructors["graph"])
+}
+
+func BenchmarkArrayGraph(b *testing.B) {
+	benchmarkDependencyTracker(b, dependencyTrackerConstructors["arrayGraph"])
 }